@@ -2,10 +2,16 @@ module github.com/riza/linx
 
 go 1.17
 
-require github.com/sirupsen/logrus v1.8.1
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/klauspost/compress v1.15.15
+	github.com/sirupsen/logrus v1.8.1
+	golang.org/x/net v0.17.0
+	golang.org/x/text v0.13.0
+)
 
 require (
 	github.com/stretchr/testify v1.7.0 // indirect
-	golang.org/x/sys v0.0.0-20220624220833-87e55d714810 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// idleBody fails a read that gets no data for the read timeout, by closing
+// the body under it. Time spent between reads is not counted.
+type idleBody struct {
+	io.ReadCloser
+	url     string
+	timeout time.Duration
+	timer   *time.Timer
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+func newIdleBody(body io.ReadCloser, url string, timeout time.Duration) *idleBody {
+	b := &idleBody{ReadCloser: body, url: url, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, b.expire)
+	b.timer.Stop()
+	return b
+}
+
+func (b *idleBody) expire() {
+	b.mu.Lock()
+	b.timedOut = true
+	b.mu.Unlock()
+	b.ReadCloser.Close()
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.ReadCloser.Read(p)
+	b.timer.Stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timedOut {
+		return n, fmt.Errorf(errReadTimeout, b.url, b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
+
+// limitedBody fails once more than limit bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	url   string
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n, fmt.Errorf(errResponseTooLarge, b.url, b.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,247 @@
+package httpclient
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// cachedHeaders are the response headers kept along with a cached body.
+var cachedHeaders = []string{"Content-Type", "Content-Encoding", "ETag", "Last-Modified"}
+
+// cache is an on-disk HTTP cache keyed by URL. Responses with an ETag or a
+// Last-Modified header are stored as they were received, and requests for
+// them are made conditional; on 304 Not Modified the stored body is used.
+type cache struct {
+	dir       string
+	transport http.RoundTripper
+}
+
+// cacheEntry is the first line of a cache file, the body follows it.
+type cacheEntry struct {
+	URL    string
+	Header http.Header
+}
+
+func newCache(dir string, transport http.RoundTripper) (*cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &cache{dir: dir, transport: transport}, nil
+}
+
+func (c *cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.transport.RoundTrip(req)
+	}
+
+	name := c.path(req.URL.String())
+	entry, ok := c.load(name)
+	if ok {
+		req = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		cached, err := c.response(name, entry, req)
+		resp.Body.Close()
+		if err == nil {
+			logger.Get().Debugf("not modified, using cached response url=%s", req.URL)
+			return cached, nil
+		}
+
+		// the request is sent again without the conditions, straight to the
+		// transport so a cache file that can not be removed is not used again
+		logger.Get().Debugf("cached response can not be read url=%s err: %v", req.URL, err)
+		if err := os.Remove(name); err != nil {
+			logger.Get().Debugf("cached response can not be removed url=%s err: %v", req.URL, err)
+		}
+		resp, err = c.transport.RoundTrip(stripConditions(req))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK && cacheable(resp) {
+		body, err := c.store(name, req.URL.String(), resp)
+		if err != nil {
+			logger.Get().Debugf("response can not be cached url=%s err: %v", req.URL, err)
+			return resp, nil
+		}
+		resp.Body = body
+	}
+
+	return resp, nil
+}
+
+func (c *cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// load reads the entry of a cache file, without its body.
+func (c *cache) load(name string) (cacheEntry, bool) {
+	f, err := os.Open(name)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	defer f.Close()
+
+	var entry cacheEntry
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil || json.Unmarshal(line, &entry) != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// response builds a 200 response from a cache file.
+func (c *cache) response(name string, entry cacheEntry, req *http.Request) (*http.Response, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          &fileBody{Reader: r, f: f},
+		ContentLength: stat.Size() - int64(len(line)),
+		Request:       req,
+	}, nil
+}
+
+// store returns a body that writes the response to a temporary file as it
+// is read, which replaces the cache file once the whole body is read.
+func (c *cache) store(name, url string, resp *http.Response) (io.ReadCloser, error) {
+	tmp, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return nil, err
+	}
+
+	entry := cacheEntry{URL: url, Header: http.Header{}}
+	for _, h := range cachedHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			entry.Header.Set(h, v)
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err == nil {
+		_, err = tmp.Write(append(line, '\n'))
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &cacheWriter{ReadCloser: resp.Body, tmp: tmp, name: name}, nil
+}
+
+// cacheable reports whether a response may be stored and revalidated.
+func cacheable(resp *http.Response) bool {
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+func stripConditions(req *http.Request) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+	return req
+}
+
+// cacheWriter copies a body to the temporary file while it is read. The
+// cache file is only replaced when the body is read to its end.
+type cacheWriter struct {
+	io.ReadCloser
+	tmp    *os.File
+	name   string
+	failed bool
+	done   bool
+}
+
+func (w *cacheWriter) Read(p []byte) (int, error) {
+	n, err := w.ReadCloser.Read(p)
+	if n > 0 && !w.failed {
+		if _, werr := w.tmp.Write(p[:n]); werr != nil {
+			w.failed = true
+		}
+	}
+	if err == io.EOF {
+		w.finish()
+	}
+	return n, err
+}
+
+func (w *cacheWriter) finish() {
+	if w.done {
+		return
+	}
+	w.done = true
+
+	if err := w.tmp.Close(); err != nil || w.failed {
+		os.Remove(w.tmp.Name())
+		return
+	}
+	if err := os.Rename(w.tmp.Name(), w.name); err != nil {
+		os.Remove(w.tmp.Name())
+	}
+}
+
+func (w *cacheWriter) Close() error {
+	if !w.done {
+		// a body that is not read to its end is not cached
+		w.done = true
+		w.tmp.Close()
+		os.Remove(w.tmp.Name())
+	}
+	return w.ReadCloser.Close()
+}
+
+// fileBody is a cached body read from its cache file.
+type fileBody struct {
+	io.Reader
+	f *os.File
+}
+
+func (b *fileBody) Close() error {
+	return b.f.Close()
+}
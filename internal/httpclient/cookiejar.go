@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in the Netscape format
+const httpOnlyPrefix = "#HttpOnly_"
+
+// loadCookieJar reads a Netscape cookie file into the jar. Each line holds
+// domain, include subdomains, path, secure, expiry, name and value separated
+// by tabs. Expired cookies are skipped.
+func loadCookieJar(jar http.CookieJar, name string) (int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			continue
+		}
+
+		domain := fields[0]
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+
+		host := strings.TrimPrefix(domain, ".")
+		// a cookie is sent to subdomains when it has a domain attribute
+		if strings.EqualFold(fields[1], "TRUE") || strings.HasPrefix(domain, ".") {
+			cookie.Domain = host
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
+		n++
+	}
+
+	return n, s.Err()
+}
@@ -0,0 +1,350 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// Config holds what is sent along with every request.
+type Config struct {
+	// Headers are "Name: value" pairs
+	Headers []string
+	// Cookie is a "name=value; name2=value2" Cookie header
+	Cookie string
+	// CookieJar is a cookie file in the Netscape format, as written by curl
+	// and browser extensions
+	CookieJar string
+	// BearerToken and BasicAuth, as "user:password", set the Authorization
+	// header
+	BearerToken string
+	BasicAuth   string
+
+	// Proxy is an http, https or socks5 proxy URL, HTTP_PROXY and
+	// HTTPS_PROXY are honored without it
+	Proxy string
+	// CACert is a PEM file of certificates trusted along with the system ones
+	CACert   string
+	Insecure bool
+	// ClientCert and ClientKey are the PEM files of a client certificate,
+	// the key may be in the certificate file
+	ClientCert string
+	ClientKey  string
+
+	// ConnectTimeout bounds dialing and the TLS handshake, ReadTimeout the
+	// wait for the response headers and for every read of the body, and
+	// Timeout the whole request; zero means no limit
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	Timeout        time.Duration
+	// Retries is how many times a request failing with a network error, a
+	// 5xx or a 429 response is sent again
+	Retries int
+	// MaxSize is the size limit of a response body in bytes, zero means no
+	// limit
+	MaxSize int64
+	// Rate limits the requests to each host, like 5/s or 100/m
+	Rate string
+	// CacheDir is where fetched responses are cached, empty disables the
+	// cache
+	CacheDir string
+	// Resolve pins host:port addresses to IPs, like host:443:10.0.0.5
+	Resolve []string
+	// Redirects is the redirect policy: follow, same-host or none
+	Redirects string
+}
+
+// Client makes every HTTP request of linx, so they all carry the configured
+// headers, cookies and credentials and go through one shared transport.
+type Client struct {
+	c      *http.Client
+	header http.Header
+	// host overrides the Host header of requests to the hosts of targets
+	// and to pinned hosts, when it is set
+	host string
+	// hostMu guards hostTargets, the hosts the Host header is overridden for
+	hostMu      sync.Mutex
+	hostTargets map[string]bool
+
+	retries     int
+	readTimeout time.Duration
+	maxSize     int64
+	hosts       *limiter
+}
+
+var c *Client
+
+func init() {
+	c = &Client{
+		c:           &http.Client{},
+		header:      http.Header{"User-Agent": {defaultUserAgent}},
+		hostTargets: make(map[string]bool),
+		hosts:       newLimiter(0),
+	}
+}
+
+func Get() *Client {
+	return c
+}
+
+// Configure applies the config to the requests made from now on.
+func (c *Client) Configure(cfg Config) error {
+	for _, h := range cfg.Headers {
+		i := strings.Index(h, ":")
+		if i <= 0 {
+			return fmt.Errorf(errHeaderIsInvalid, h)
+		}
+		name, value := strings.TrimSpace(h[:i]), strings.TrimSpace(h[i+1:])
+		if strings.EqualFold(name, "Host") {
+			// the Host header is only sent to the hosts of targets and to
+			// pinned hosts, not to the other hosts scripts are fetched from
+			c.host = value
+			continue
+		}
+		c.header.Set(name, value)
+	}
+
+	if cfg.Cookie != "" {
+		c.header.Set("Cookie", cfg.Cookie)
+	}
+
+	if cfg.BasicAuth != "" {
+		if !strings.Contains(cfg.BasicAuth, ":") {
+			return fmt.Errorf(errBasicAuthIsInvalid)
+		}
+		c.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cfg.BasicAuth)))
+	}
+	if cfg.BearerToken != "" {
+		c.header.Set("Authorization", "Bearer "+cfg.BearerToken)
+	}
+
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return err
+	}
+	for _, entry := range cfg.Resolve {
+		// entries are valid, newTransport parsed them
+		c.hostTargets[strings.ToLower(strings.SplitN(entry, ":", 2)[0])] = true
+	}
+	c.c.Transport = transport
+	if cfg.CacheDir != "" {
+		cache, err := newCache(cfg.CacheDir, transport)
+		if err != nil {
+			return fmt.Errorf(errCacheDirIsInvalid, cfg.CacheDir, err)
+		}
+		c.c.Transport = cache
+	}
+	c.c.Timeout = cfg.Timeout
+	c.c.CheckRedirect, err = checkRedirect(cfg.Redirects)
+	if err != nil {
+		return err
+	}
+	c.retries, c.readTimeout, c.maxSize = cfg.Retries, cfg.ReadTimeout, cfg.MaxSize
+
+	rate, err := parseRate(cfg.Rate)
+	if err != nil {
+		return err
+	}
+	c.hosts = newLimiter(rate)
+
+	if cfg.CookieJar != "" {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return err
+		}
+		n, err := loadCookieJar(jar, cfg.CookieJar)
+		if err != nil {
+			return fmt.Errorf(errCookieJarIsInvalid, cfg.CookieJar, err)
+		}
+		logger.Get().Debugf("loaded %d cookies from jar=%s", n, cfg.CookieJar)
+		c.c.Jar = jar
+	}
+
+	return nil
+}
+
+// newTransport returns the transport shared by every request, with the
+// proxy and TLS settings of the config.
+func newTransport(cfg Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{Timeout: cfg.ConnectTimeout, KeepAlive: 30 * time.Second}
+	if cfg.ConnectTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.ConnectTimeout
+	}
+	resolver, err := newResolver(dialer, cfg.Resolve)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = resolver.DialContext
+	transport.ResponseHeaderTimeout = cfg.ReadTimeout
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf(errProxyIsInvalid, cfg.Proxy)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		case "socks5h":
+			// host names are always resolved by socks5 proxies
+			proxy.Scheme = "socks5"
+		default:
+			return nil, fmt.Errorf(errProxyIsInvalid, cfg.Proxy)
+		}
+		logger.Get().Debugf("requests are sent through proxy=%s", proxy.Redacted())
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.Insecure}
+
+	if cfg.CACert != "" {
+		pem, err := ioutil.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf(errCACertIsInvalid, cfg.CACert, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(errCACertIsInvalid, cfg.CACert, "no certificate found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" {
+		key := cfg.ClientKey
+		if key == "" {
+			key = cfg.ClientCert
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, key)
+		if err != nil {
+			return nil, fmt.Errorf(errClientCertIsInvalid, cfg.ClientCert, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	} else if cfg.ClientKey != "" {
+		return nil, fmt.Errorf(errClientKeyWithoutCert)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// NewRequest returns a request with the configured headers set.
+func (c *Client) NewRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range c.header {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if c.host != "" && c.isTargetHost(req.URL.Hostname()) {
+		req.Host = c.host
+	}
+	return req, nil
+}
+
+// AddTarget marks the host of a target URL, the configured Host header is
+// sent to it.
+func (c *Client) AddTarget(target string) {
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		return
+	}
+
+	c.hostMu.Lock()
+	defer c.hostMu.Unlock()
+	c.hostTargets[strings.ToLower(u.Hostname())] = true
+}
+
+func (c *Client) isTargetHost(host string) bool {
+	c.hostMu.Lock()
+	defer c.hostMu.Unlock()
+	return c.hostTargets[strings.ToLower(host)]
+}
+
+// Do sends the request, again after a backoff when it fails with a network
+// error, a 5xx or a 429 response. Retry-After is honored on 429 and 503. The
+// last response is returned when retries run out. Requests wait for the rate
+// limit of their host, and for its backoff after a 429.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			// the body was sent by the previous attempt
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		c.hosts.wait(host)
+		resp, err := c.c.Do(req)
+
+		switch {
+		case resp != nil && resp.StatusCode == http.StatusTooManyRequests:
+			d, _ := retryAfter(resp.Header.Get("Retry-After"))
+			c.hosts.throttle(host, d)
+		case err == nil:
+			c.hosts.recover(host)
+		}
+
+		delay, retry := c.retryDelay(resp, err, attempt)
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf(errRequestFailed, attempt+1, req.URL, err)
+			}
+			return c.watch(resp)
+		}
+
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = resp.Status
+			discard(resp)
+		}
+		logger.Get().Debugf("retrying in %s attempt=%d url=%s reason=%s", delay, attempt+1, req.URL, reason)
+		time.Sleep(delay)
+	}
+}
+
+// watch applies the size limit and the read timeout to a response.
+func (c *Client) watch(resp *http.Response) (*http.Response, error) {
+	target := resp.Request.URL.String()
+
+	if c.maxSize > 0 && resp.ContentLength > c.maxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf(errResponseTooLarge, target, c.maxSize)
+	}
+	if c.readTimeout > 0 {
+		resp.Body = newIdleBody(resp.Body, target, c.readTimeout)
+	}
+	return resp, nil
+}
+
+// LimitBody applies the size limit to a decoded response body, so
+// compressed responses can not grow past it either.
+func (c *Client) LimitBody(body io.ReadCloser, target string) io.ReadCloser {
+	if c.maxSize <= 0 {
+		return body
+	}
+	return &limitedBody{ReadCloser: body, url: target, limit: c.maxSize}
+}
@@ -0,0 +1,22 @@
+package httpclient
+
+const (
+	errHeaderIsInvalid    = "header is invalid, it must be 'Name: value' header=%s"
+	errBasicAuthIsInvalid = "basic auth is invalid, it must be user:password"
+	errCookieJarIsInvalid = "cookie jar can not be read jar=%s err: %v"
+	errCacheDirIsInvalid  = "cache directory can not be created dir=%s err: %v"
+
+	errProxyIsInvalid       = "proxy is invalid, it must be an http, https or socks5 url proxy=%s"
+	errCACertIsInvalid      = "ca certificate can not be loaded file=%s err: %v"
+	errClientCertIsInvalid  = "client certificate can not be loaded file=%s err: %v"
+	errClientKeyWithoutCert = "client key is given without a client certificate"
+	errResolveIsInvalid     = "resolve entry is invalid, it must be host:port:ip entry=%s"
+
+	errRequestFailed    = "request failed after %d attempts url=%s err: %w"
+	errReadTimeout      = "response read timed out url=%s timeout=%s"
+	errResponseTooLarge = "response is larger than the size limit url=%s limit=%d"
+	errRateIsInvalid    = "rate is invalid, it must be like 5/s, 100/m or 1000/h rate=%s"
+
+	errRedirectPolicyIsInvalid = "redirect policy is invalid, it must be follow, same-host or none redirects=%s"
+	errTooManyRedirects        = "stopped after %d redirects"
+)
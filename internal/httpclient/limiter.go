@@ -0,0 +1,142 @@
+package httpclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// limiter spaces the requests to every host by the rate limit, and backs
+// off hosts that answer 429 Too Many Requests, longer each time they do.
+type limiter struct {
+	// rate is the number of requests per second to a host, zero means no
+	// limit
+	rate float64
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// hostState is the token bucket of a host along with its backoff.
+type hostState struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	// requests wait until pausedUntil after a 429, strikes counts the 429s
+	// in a row
+	pausedUntil time.Time
+	strikes     int
+}
+
+func newLimiter(rate float64) *limiter {
+	return &limiter{rate: rate, hosts: make(map[string]*hostState)}
+}
+
+func (l *limiter) host(name string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.hosts[name]
+	if !ok {
+		h = &hostState{tokens: 1, last: time.Now()}
+		l.hosts[name] = h
+	}
+	return h
+}
+
+// wait blocks until a request may be sent to the host.
+func (l *limiter) wait(name string) {
+	h := l.host(name)
+
+	for {
+		h.mu.Lock()
+		now := time.Now()
+
+		if now.Before(h.pausedUntil) {
+			d := h.pausedUntil.Sub(now)
+			h.mu.Unlock()
+			time.Sleep(d)
+			continue
+		}
+
+		if l.rate <= 0 {
+			h.mu.Unlock()
+			return
+		}
+
+		// a bucket of one token keeps requests evenly spaced
+		h.tokens += now.Sub(h.last).Seconds() * l.rate
+		if h.tokens > 1 {
+			h.tokens = 1
+		}
+		h.last = now
+
+		if h.tokens >= 1 {
+			h.tokens--
+			h.mu.Unlock()
+			return
+		}
+
+		d := time.Duration((1 - h.tokens) / l.rate * float64(time.Second))
+		h.mu.Unlock()
+		time.Sleep(d)
+	}
+}
+
+// throttle pauses a host that answered 429, for the Retry-After delay when
+// it is longer than the backoff of its strikes.
+func (l *limiter) throttle(name string, retryAfter time.Duration) {
+	h := l.host(name)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d := backoff(h.strikes)
+	if retryAfter > d {
+		d = retryAfter
+	}
+	h.strikes++
+
+	if until := time.Now().Add(d); until.After(h.pausedUntil) {
+		h.pausedUntil = until
+		logger.Get().Warnf("host is rate limiting, backing off for %s host=%s strikes=%d", d, name, h.strikes)
+	}
+}
+
+// recover resets the backoff of a host once it answers again.
+func (l *limiter) recover(name string) {
+	h := l.host(name)
+	h.mu.Lock()
+	h.strikes = 0
+	h.mu.Unlock()
+}
+
+// parseRate parses a rate limit like 5/s, 100/m or 5, which is per second.
+func parseRate(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	count, unit := value, "s"
+	if i := strings.Index(value, "/"); i >= 0 {
+		count, unit = value[:i], value[i+1:]
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf(errRateIsInvalid, value)
+	}
+
+	switch strings.TrimSpace(unit) {
+	case "s":
+		return n, nil
+	case "m":
+		return n / 60, nil
+	case "h":
+		return n / 3600, nil
+	}
+	return 0, fmt.Errorf(errRateIsInvalid, value)
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Redirect policies
+const (
+	// RedirectFollow follows every redirect
+	RedirectFollow = "follow"
+	// RedirectSameHost follows redirects to the host of the request only
+	RedirectSameHost = "same-host"
+	// RedirectNone follows no redirect
+	RedirectNone = "none"
+)
+
+// maxRedirects is how many redirects are followed at most, like the default
+// of net/http.
+const maxRedirects = 10
+
+// checkRedirect returns the redirect check of the policy. A redirect that is
+// not followed is returned as the response.
+func checkRedirect(policy string) (func(req *http.Request, via []*http.Request) error, error) {
+	switch policy {
+	case "", RedirectFollow:
+		return nil, nil
+	case RedirectSameHost:
+		return func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf(errTooManyRedirects, maxRedirects)
+			}
+			// http to https on the same host is still followed
+			if !strings.EqualFold(req.URL.Hostname(), via[0].URL.Hostname()) {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		}, nil
+	case RedirectNone:
+		return func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}, nil
+	}
+	return nil, fmt.Errorf(errRedirectPolicyIsInvalid, policy)
+}
@@ -0,0 +1,62 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// resolver pins host:port addresses to IPs, like curl's --resolve, so hosts
+// without DNS records can be reached under their own name. The URL, the Host
+// header and the TLS server name are left untouched.
+type resolver struct {
+	dialer *net.Dialer
+	// addresses maps a lowercase host:port to the ip:port addresses to dial
+	addresses map[string][]string
+}
+
+// newResolver parses entries like example.com:443:10.0.0.5, several IPs may
+// be given separated by commas and IPv6 addresses in brackets.
+func newResolver(dialer *net.Dialer, entries []string) (*resolver, error) {
+	r := &resolver{dialer: dialer, addresses: make(map[string][]string)}
+
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf(errResolveIsInvalid, entry)
+		}
+		host, port := strings.ToLower(parts[0]), parts[1]
+
+		var addresses []string
+		for _, ip := range strings.Split(parts[2], ",") {
+			ip = strings.Trim(strings.TrimSpace(ip), "[]")
+			if net.ParseIP(ip) == nil {
+				return nil, fmt.Errorf(errResolveIsInvalid, entry)
+			}
+			addresses = append(addresses, net.JoinHostPort(ip, port))
+		}
+		r.addresses[net.JoinHostPort(host, port)] = addresses
+	}
+
+	return r, nil
+}
+
+// DialContext dials the pinned addresses of addr in order, or addr itself
+// when it is not pinned.
+func (r *resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	addresses, ok := r.addresses[strings.ToLower(addr)]
+	if !ok {
+		return r.dialer.DialContext(ctx, network, addr)
+	}
+
+	var err error
+	for _, a := range addresses {
+		var conn net.Conn
+		conn, err = r.dialer.DialContext(ctx, network, a)
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
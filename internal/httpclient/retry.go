@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// the first retry waits minBackoff, every following one twice as long up
+	// to maxBackoff
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+	// Retry-After values longer than this are cut to it
+	maxRetryAfter = 2 * time.Minute
+)
+
+// retryDelay reports whether a request should be sent again after the
+// response or error of an attempt, and how long to wait before it.
+func (c *Client) retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.retries {
+		return 0, false
+	}
+
+	if err != nil {
+		if !isTemporary(err) {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return d, true
+		}
+		return backoff(attempt), true
+	case resp.StatusCode >= 500:
+		return backoff(attempt), true
+	}
+	return 0, false
+}
+
+// isTemporary reports whether a request error may go away on retry: network
+// errors and timeouts, but not unknown hosts or invalid certificates.
+func isTemporary(err error) bool {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		err = uerr.Err
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func backoff(attempt int) time.Duration {
+	d := minBackoff << uint(attempt)
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+	return d
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as a date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var d time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = time.Until(t)
+	} else {
+		return 0, false
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d, true
+}
+
+// discard reads what is left of a response body so its connection is reused.
+func discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
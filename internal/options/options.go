@@ -1,19 +1,82 @@
 package options
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/riza/linx/internal/httpclient"
+	"github.com/riza/linx/internal/scanner/strategies"
 	"github.com/riza/linx/pkg/logger"
 )
 
+// StdinTarget is the target name used to scan raw JavaScript piped on stdin.
+const StdinTarget = "-"
+
+// Ways an HTML page returned for a script URL is handled
+const (
+	Soft404Skip = "skip"
+	Soft404HTML = "html"
+	Soft404Scan = "scan"
+)
+
 type Options struct {
-	Target   string
+	Args     []string
+	List     string
 	Output   string
 	Debug    bool
 	Parallel bool
+
+	Include         listFlag
+	Exclude         listFlag
+	SkipNodeModules bool
+
+	Crawl bool
+	Depth int
+	Scope listFlag
+
+	NoSourceMap bool
+	NoChunks    bool
+
+	MaxEntrySize    int
+	MaxArchiveDepth int
+
+	Headers     repeatedFlag
+	Cookie      string
+	CookieJar   string
+	BearerToken string
+	BasicAuth   string
+
+	Proxy      string
+	CACert     string
+	Insecure   bool
+	ClientCert string
+	ClientKey  string
+
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	Timeout        time.Duration
+	Retries        int
+	MaxSize        int
+
+	Concurrency int
+	Rate        string
+
+	CacheDir string
+	NoCache  bool
+
+	RequestBase string
+	Resolve     repeatedFlag
+	Soft404     string
+	Redirects   string
+
+	// stdinTargets is set when targets are read line by line from stdin
+	stdinTargets bool
 }
 
 var (
@@ -32,7 +95,41 @@ func (o *Options) Parse() (*Options, error) {
 
 	flag.BoolVar(&o.Debug, "debug", false, "do you want to know what's inside the engine?")
 	flag.StringVar(&o.Output, "output", "", "output file name (supports html and json formats)")
-	flag.BoolVar(&o.Parallel, "parallel", false, "scan multiple targets in parallel (only works with comma separated targets)")
+	flag.BoolVar(&o.Parallel, "parallel", false, "scan multiple targets in parallel")
+	flag.IntVar(&o.Concurrency, "concurrency", 10, "number of targets scanned at once in parallel mode")
+	flag.StringVar(&o.Rate, "rate", "", "maximum requests per host, like 5/s or 100/m (default: no limit)")
+	flag.StringVar(&o.CacheDir, "cache-dir", defaultCacheDir(), "directory where fetched scripts are cached and revalidated")
+	flag.BoolVar(&o.NoCache, "no-cache", false, "do not cache fetched scripts")
+	flag.Var(&o.Resolve, "resolve", "connect to an ip for a host and port instead of resolving it, like example.com:443:10.0.0.5 (repeatable)")
+	flag.StringVar(&o.RequestBase, "request-base", "", "scheme and host raw request files are sent to, like https://example.com (default: https and the Host header)")
+	flag.StringVar(&o.Soft404, "soft-404", Soft404Skip, "what to do with an html page returned for a script url: skip it as a soft 404, html to scan the scripts of the page, or scan it as a script")
+	flag.StringVar(&o.Redirects, "redirects", httpclient.RedirectFollow, "redirects to follow: follow, same-host to stay on the host of the request, or none")
+	flag.StringVar(&o.List, "l", "", "file containing targets, one per line")
+	flag.Var(&o.Include, "include", "only scan files matching these globs when walking directories (comma separated, repeatable)")
+	flag.Var(&o.Exclude, "exclude", "skip files and directories matching these globs (comma separated, repeatable)")
+	flag.BoolVar(&o.SkipNodeModules, "skip-node-modules", false, "do not descend into node_modules directories")
+	flag.BoolVar(&o.Crawl, "crawl", false, "follow and scan the js files found in scanned scripts")
+	flag.IntVar(&o.Depth, "depth", 2, "maximum crawl depth")
+	flag.BoolVar(&o.NoSourceMap, "no-sourcemap", false, "do not load source maps of scanned scripts")
+	flag.BoolVar(&o.NoChunks, "no-chunks", false, "do not scan the chunks found in webpack runtimes and build manifests")
+	flag.IntVar(&o.MaxEntrySize, "max-entry-size", strategies.DefaultMaxEntrySize>>20, "maximum size in MB of an archive entry or of a decompressed .gz, .br or .zst file, larger ones are skipped")
+	flag.IntVar(&o.MaxArchiveDepth, "max-archive-depth", strategies.DefaultMaxArchiveDepth, "maximum nesting of archives inside archives")
+	flag.Var(&o.Scope, "scope", "hosts allowed when crawling, wildcards like *.example.com are supported (default: host of the script)")
+	flag.Var(&o.Headers, "H", "header sent with every request, like 'Name: value', a Host header is sent to the hosts of targets and to resolved hosts only (repeatable)")
+	flag.StringVar(&o.Cookie, "cookie", "", "cookies sent with every request, like 'name=value; name2=value2'")
+	flag.StringVar(&o.CookieJar, "cookie-jar", "", "netscape format cookie file, cookies are sent to their domains")
+	flag.StringVar(&o.BearerToken, "bearer", "", "bearer token sent in the authorization header")
+	flag.StringVar(&o.BasicAuth, "basic-auth", "", "basic auth credentials, like user:password")
+	flag.StringVar(&o.Proxy, "proxy", "", "http, https or socks5 proxy url (default: HTTP_PROXY and HTTPS_PROXY)")
+	flag.StringVar(&o.CACert, "ca-cert", "", "pem file of ca certificates to trust, like the one of an intercepting proxy")
+	flag.BoolVar(&o.Insecure, "insecure", false, "do not verify tls certificates")
+	flag.StringVar(&o.ClientCert, "client-cert", "", "pem file of a client certificate for mutual tls")
+	flag.StringVar(&o.ClientKey, "client-key", "", "pem file of the client certificate's key (default: the client certificate file)")
+	flag.DurationVar(&o.ConnectTimeout, "connect-timeout", 10*time.Second, "timeout of connecting to a host, tls handshake included")
+	flag.DurationVar(&o.ReadTimeout, "read-timeout", 30*time.Second, "timeout of waiting for response headers and for each read of a response body")
+	flag.DurationVar(&o.Timeout, "timeout", 0, "timeout of a whole request, 0 means no limit")
+	flag.IntVar(&o.Retries, "retries", 2, "times a request failing with a network error, 5xx or 429 is retried")
+	flag.IntVar(&o.MaxSize, "max-size", 0, "maximum size in MB of a response body, 0 means no limit")
 
 	// Parse flags, but the first non-flag argument will be our target
 	flag.Parse()
@@ -41,49 +138,205 @@ func (o *Options) Parse() (*Options, error) {
 		logger.Get().SetLevelDebug()
 	}
 
-	// Get positional arguments
-	args := flag.Args()
-	if len(args) == 0 {
-		printDefaults()
-		return nil, fmt.Errorf("target is required")
+	switch o.Soft404 {
+	case Soft404Skip, Soft404HTML, Soft404Scan:
+	default:
+		return nil, fmt.Errorf(errSoft404IsInvalid, o.Soft404)
 	}
 
-	// First positional argument is the target
-	o.Target = strings.Join(args, ",")
+	cacheDir := o.CacheDir
+	if o.NoCache {
+		cacheDir = ""
+	}
 
-	isValid := validateTarget(o.Target)
-	if !isValid {
+	err := httpclient.Get().Configure(httpclient.Config{
+		Headers:        o.Headers,
+		Cookie:         o.Cookie,
+		CookieJar:      o.CookieJar,
+		BearerToken:    o.BearerToken,
+		BasicAuth:      o.BasicAuth,
+		Proxy:          o.Proxy,
+		CACert:         o.CACert,
+		Insecure:       o.Insecure,
+		ClientCert:     o.ClientCert,
+		ClientKey:      o.ClientKey,
+		ConnectTimeout: o.ConnectTimeout,
+		ReadTimeout:    o.ReadTimeout,
+		Timeout:        o.Timeout,
+		Retries:        o.Retries,
+		MaxSize:        int64(o.MaxSize) << 20,
+		Rate:           o.Rate,
+		CacheDir:       cacheDir,
+		Resolve:        o.Resolve,
+		Redirects:      o.Redirects,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Get positional arguments, comma separated targets are still supported
+	for _, arg := range flag.Args() {
+		for _, t := range strings.Split(arg, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" {
+				continue
+			}
+
+			if !validateTarget(t) {
+				printDefaults()
+				return nil, fmt.Errorf(errTargetIsInvalid, t)
+			}
+			o.Args = append(o.Args, t)
+		}
+	}
+
+	if o.List != "" {
+		if _, err := os.Stat(o.List); err != nil {
+			return nil, fmt.Errorf(errListIsInvalid, o.List, err)
+		}
+	}
+
+	// Targets are read from stdin when it is piped and nothing else was given
+	if len(o.Args) == 0 && o.List == "" && isStdinPiped() {
+		o.stdinTargets = true
+	}
+
+	if len(o.Args) == 0 && o.List == "" && !o.stdinTargets {
 		printDefaults()
-		return nil, fmt.Errorf("target is invalid: %s", o.Target)
+		return nil, fmt.Errorf("target is required")
 	}
 
 	return o, nil
 }
 
-func validateTarget(target string) (isValid bool) {
-	// Support for multiple targets (comma separated)
-	targets := strings.Split(target, ",")
+// HasMultipleTargets reports whether targets may come from more than one
+// argument, a list file or stdin.
+func (o *Options) HasMultipleTargets() bool {
+	return len(o.Args) > 1 || o.List != "" || o.stdinTargets
+}
+
+// Targets streams every target from the positional arguments, the list file
+// and stdin, in that order. Invalid targets are logged and skipped.
+func (o *Options) Targets() <-chan string {
+	targets := make(chan string)
 
-	for _, t := range targets {
-		t = strings.TrimSpace(t)
-		isCurrentValid := false
+	go func() {
+		defer close(targets)
 
-		// Check if it's a URL to a JS file
-		if (strings.Contains(t, "http://") || strings.Contains(t, "https://")) && strings.Contains(t, ".js") {
-			isCurrentValid = true
+		for _, t := range o.Args {
+			targets <- t
 		}
 
-		// Check if it's a local JS file
-		if strings.Contains(t, ".js") {
-			isCurrentValid = true
+		if o.List != "" {
+			f, err := os.Open(o.List)
+			if err != nil {
+				logger.Get().Errorf(errListIsInvalid, o.List, err)
+			} else {
+				readTargets(f, targets)
+				f.Close()
+			}
 		}
 
-		if !isCurrentValid {
-			return false
+		if o.stdinTargets {
+			readTargets(os.Stdin, targets)
 		}
+	}()
+
+	return targets
+}
+
+func readTargets(r io.Reader, targets chan<- string) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for s.Scan() {
+		t := strings.TrimSpace(s.Text())
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+
+		if !validateTarget(t) {
+			logger.Get().Warnf(errTargetIsInvalid, t)
+			continue
+		}
+		targets <- t
+	}
+
+	if err := s.Err(); err != nil {
+		logger.Get().Errorf("reading targets failed: %v", err)
+	}
+}
+
+func validateTarget(t string) (isValid bool) {
+	// Raw JavaScript piped on stdin
+	if t == StdinTarget {
+		return true
+	}
+
+	// URLs may point to a JS file or to an HTML page
+	if strings.Contains(t, "http://") || strings.Contains(t, "https://") {
+		return true
+	}
+
+	// Check if it's a local directory, script file or HTML page, a path
+	// that does not exist may be a glob expanded while walking
+	stat, err := os.Stat(t)
+	if err != nil {
+		return strategies.IsGlob(t)
+	}
+
+	// json files may be build manifests, har, xml and warc files recorded traffic
+	// and other files raw requests to replay
+	ext := strings.ToLower(filepath.Ext(t))
+	return stat.IsDir() || strategies.IsScript(t) || strategies.IsReactNativeBundle(t) || strategies.IsHTML(t) || strategies.IsWARC(t) ||
+		strategies.IsArchive(t) || strategies.IsAsar(t) || ext == ".json" || ext == ".har" || ext == ".xml" || strategies.IsRawRequest(t)
+}
+
+// listFlag collects comma separated values of a flag that can be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// repeatedFlag collects the values of a flag that can be repeated, values
+// are kept whole since headers may hold commas.
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// defaultCacheDir is the linx directory in the user's cache directory, the
+// cache is disabled when there is none.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
 	}
+	return filepath.Join(dir, "linx")
+}
 
-	return true
+func isStdinPiped() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice == 0
 }
 
 func printDefaults() {
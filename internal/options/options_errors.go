@@ -3,4 +3,6 @@ package options
 const (
 	errTargetIsRequired = "target required, must be not empty target=%s"
 	errTargetIsInvalid  = "target is invalid, it must be url or file path target=%s"
+	errListIsInvalid    = "target list can not be read list=%s err: %v"
+	errSoft404IsInvalid = "soft 404 handling is invalid, it must be skip, html or scan soft-404=%s"
 )
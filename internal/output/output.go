@@ -1,5 +1,19 @@
 package output
 
+// Result kinds, results without a kind are possible urls
+const (
+	KindSourceMap = "sourcemap"
+	KindRoute     = "route"
+	KindHermes    = "hermes"
+)
+
+// Decisions taken for an HTML page returned for a script URL
+const (
+	DecisionSoft404 = "soft-404"
+	DecisionHTML    = "html"
+	DecisionScanned = "scanned"
+)
+
 type Output interface {
 	RenderAndSave(data *OutputData) error
 }
@@ -8,9 +22,51 @@ type OutputData struct {
 	Target   string
 	Filename string
 	Results  []Result
+	// Failures lists what could not be scanned and why
+	Failures []Failure `json:",omitempty"`
+	// Responses lists the responses that were redirected or were not what
+	// their URL suggests, and how they were handled
+	Responses []Response `json:",omitempty"`
+}
+
+// Failure records why the target, or a script found in it, could not be
+// scanned.
+type Failure struct {
+	Source string
+	Reason string
+	// Redirects lists the URLs the request was redirected to when the
+	// redirect policy stopped it, the last one is the redirect not followed
+	Redirects []string `json:",omitempty"`
+}
+
+// Response records the redirects of a request, and the decision taken when
+// the content of the response does not match its URL, like an HTML page
+// returned for a script.
+type Response struct {
+	URL string
+	// Redirects lists the URLs the request was redirected to, the last one
+	// is the base relative results of the response are resolved against
+	Redirects   []string `json:",omitempty"`
+	ContentType string   `json:",omitempty"`
+	Decision    string   `json:",omitempty"`
 }
 
 type Result struct {
 	URL      string
 	Location string
+	// Source is the file the result was found in when the target holds
+	// more than one script
+	Source string `json:",omitempty"`
+	// Page is the HTML page that referenced or embedded the source
+	Page string `json:",omitempty"`
+	// Chain lists the scripts that led to the source when crawling
+	Chain []string `json:",omitempty"`
+	// File and Line locate the result in the original source when the
+	// script has a source map
+	File string `json:",omitempty"`
+	Line int    `json:",omitempty"`
+	// Offset is the byte offset of the match in the source as it was read,
+	// before its conversion to UTF-8, nil for the sources of a source map
+	Offset *int   `json:",omitempty"`
+	Kind   string `json:",omitempty"`
 }
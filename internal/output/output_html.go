@@ -90,6 +90,9 @@ const htmlTemplate = `<!doctype html>
                         <option value="static">Static Resources</option>
                         <option value="external">External URLs</option>
                         <option value="relative">Relative Paths</option>
+                        <option value="sourcemap">Source Maps</option>
+                        <option value="route">Page Routes</option>
+                        <option value="hermes">Hermes Bundles</option>
                     </select>
                 </div>
             </div>
@@ -101,6 +104,24 @@ const htmlTemplate = `<!doctype html>
         <strong>Displayed:</strong> <span id="displayedCount">{{ len .Results }}</span>
     </div>
 
+    {{ if .Failures }}
+    <div class="alert alert-warning">
+        <strong>Not scanned:</strong>
+        <ul class="mb-0">
+            {{ range .Failures }}<li><code>{{ .Source }}</code>{{ range .Redirects }} &rarr; <code>{{ . }}</code>{{ end }}: {{ .Reason }}</li>{{ end }}
+        </ul>
+    </div>
+    {{ end }}
+
+    {{ if .Responses }}
+    <div class="alert alert-secondary">
+        <strong>Responses:</strong>
+        <ul class="mb-0">
+            {{ range .Responses }}<li><code>{{ .URL }}</code>{{ range .Redirects }} &rarr; <code>{{ . }}</code>{{ end }}{{ if .ContentType }} ({{ .ContentType }}){{ end }}{{ if .Decision }}: {{ .Decision }}{{ end }}</li>{{ end }}
+        </ul>
+    </div>
+    {{ end }}
+
     <div class="table-container">
         <table class="table table-striped table-hover">
             <thead class="table-light">
@@ -116,7 +137,11 @@ const htmlTemplate = `<!doctype html>
                     <div class="d-flex justify-content-between">
                         <div>
                             <span class="url-text">{{ .URL }}</span>
-                            <span class="type-badge badge bg-secondary" data-type="unknown">analyzing...</span>
+                            <span class="type-badge badge bg-secondary" data-type="unknown" data-kind="{{ .Kind }}">analyzing...</span>
+                            {{ if .Source }}<div class="text-muted small">{{ .Source }}</div>{{ end }}
+                            {{ if .Page }}<div class="text-muted small">page: {{ .Page }}</div>{{ end }}
+                            {{ if .File }}<div class="text-muted small">original: {{ .File }}{{ if .Line }}:{{ .Line }}{{ end }}</div>{{ end }}
+                            {{ if .Chain }}<div class="text-muted small">via: {{ range $i, $c := .Chain }}{{ if $i }} &rarr; {{ end }}{{ $c }}{{ end }}</div>{{ end }}
                         </div>
                         <div>
                             <i class="bi bi-clipboard copy-btn" data-clipboard-text="{{ .URL }}" title="Copy URL"></i>
@@ -147,7 +172,7 @@ const htmlTemplate = `<!doctype html>
     document.addEventListener('DOMContentLoaded', function() {
         const results = [
             {{range .Results}}
-            { url: '{{.URL}}', location: '{{.Location}}' },
+            { url: '{{.URL}}', location: '{{.Location}}', source: '{{.Source}}', page: '{{.Page}}', file: '{{.File}}', line: {{.Line}}, offset: {{if .Offset}}{{.Offset}}{{else}}null{{end}}, kind: '{{.Kind}}' },
             {{end}}
         ];
         
@@ -209,7 +234,10 @@ const htmlTemplate = `<!doctype html>
                 let type = 'unknown';
                 let badgeClass = 'bg-secondary';
                 
-                if (url.match(/^(https?:)?\/\//) && url.match(/api|graphql|service|\/v[0-9]+\//i)) {
+                if (badge.dataset.kind) {
+                    type = badge.dataset.kind;
+                    badgeClass = 'bg-dark';
+                } else if (url.match(/^(https?:)?\/\//) && url.match(/api|graphql|service|\/v[0-9]+\//i)) {
                     type = 'api';
                     badgeClass = 'bg-danger';
                 } else if (url.match(/^(https?:)?\/\//)) {
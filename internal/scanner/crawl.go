@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/riza/linx/internal/output"
+	"github.com/riza/linx/internal/scanner/strategies"
+	"github.com/riza/linx/pkg/logger"
+)
+
+// crawlJunkChars are characters a result url can not contain, found when a
+// match runs across code instead of a string literal
+const crawlJunkChars = " \t\r\n\"'`"
+
+// crawl queues the scripts referenced by the results of a unit. References
+// are resolved against the unit and only fetched when they are within the
+// depth limit and the host scope, and have not been visited yet.
+func (s scanner) crawl(u unit, results []output.Result, j *job) {
+	if u.depth >= s.opts.Depth {
+		return
+	}
+
+	location := s.location(u)
+
+	for _, r := range results {
+		if isJunkReference(r.URL) {
+			logger.Get().Debugf("crawl: not a url, skipped result=%s", r.URL)
+			continue
+		}
+
+		target, ok := strategies.ResolveReference(location, "", r.URL)
+		if !ok || j.visited[target] {
+			continue
+		}
+
+		ref, err := url.Parse(target)
+		if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") || !strategies.IsScript(ref.Path) {
+			continue
+		}
+
+		if !s.inScope(location, ref.Hostname()) {
+			logger.Get().Debugf("crawl: out of scope url=%s", target)
+			continue
+		}
+
+		if j.discovered(u, location, target, u.depth+1) {
+			logger.Get().Debugf("crawl: queued url=%s depth=%d", target, u.depth+1)
+		}
+	}
+}
+
+// isJunkReference reports whether a result is a match spanning code, like
+// var a="/static/app.js, rather than a url. An equals sign is only allowed in
+// the query and the fragment.
+func isJunkReference(ref string) bool {
+	if strings.ContainsAny(ref, crawlJunkChars) {
+		return true
+	}
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	return strings.Contains(ref, "=")
+}
+
+// location is where the unit's content came from, used to resolve relative
+// references found in it. It is the URL the source was redirected to, if any.
+func (s scanner) location(u unit) string {
+	if u.base != "" {
+		return u.base
+	}
+	if u.source != "" {
+		return u.source
+	}
+	return s.task.target
+}
+
+// inScope reports whether host may be crawled. Without a scope only the host
+// of the script the reference was found in is allowed.
+func (s scanner) inScope(location, host string) bool {
+	if len(s.opts.Scope) == 0 {
+		l, err := url.Parse(location)
+		return err == nil && strings.EqualFold(l.Hostname(), host)
+	}
+
+	for _, scope := range s.opts.Scope {
+		if matchHost(scope, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchHost(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if strings.HasPrefix(pattern, "*.") {
+		return host == pattern[2:] || strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
@@ -0,0 +1,241 @@
+package scanner
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/riza/linx/internal/output"
+	"github.com/riza/linx/internal/scanner/strategies"
+	"github.com/riza/linx/pkg/logger"
+)
+
+const (
+	nextRouteRule       = `"(/[^"]*)"\s*:\s*\[`
+	nextSortedPagesRule = `sortedPages\s*:\s*\[((?:\s*"[^"]*"\s*,?)*)\]`
+	nextSSGRule         = `__SSG_MANIFEST\s*=\s*new\s+Set\(\s*\[((?:\s*"[^"]*"\s*,?)*)\]`
+	nextChunkRule       = `"(static/[^"]+\.js)"`
+	quotedStringRule    = `"([^"]*)"`
+)
+
+var (
+	nextRoutePattern       = regexp.MustCompile(nextRouteRule)
+	nextSortedPagesPattern = regexp.MustCompile(nextSortedPagesRule)
+	nextSSGPattern         = regexp.MustCompile(nextSSGRule)
+	nextChunkPattern       = regexp.MustCompile(nextChunkRule)
+	quotedStringPattern    = regexp.MustCompile(quotedStringRule)
+)
+
+// buildManifest is what a framework build manifest lists: the page routes of
+// the app and the chunks, relative to the manifest, that make it up.
+type buildManifest struct {
+	framework string
+	routes    []string
+	chunks    []string
+}
+
+// manifests parses the unit when it is a Next.js, Nuxt or Vite build
+// manifest. Its page routes are reported and its chunks queued for scanning.
+func (s scanner) manifests(u unit, content []byte, j *job) {
+	location := s.location(u)
+
+	m, ok := parseManifest(location, content)
+	if !ok {
+		return
+	}
+	logger.Get().Debugf("%s manifest has %d routes and %d chunks source=%s",
+		m.framework, len(m.routes), len(m.chunks), location)
+
+	for _, route := range m.routes {
+		j.out.Results = append(j.out.Results, output.Result{
+			URL:      route,
+			Location: m.framework + " page route",
+			Kind:     output.KindRoute,
+			Source:   u.source,
+			Page:     u.page,
+			Chain:    u.chain,
+		})
+		logger.Get().Infof("found page route: %s", route)
+	}
+
+	if s.opts.NoChunks {
+		return
+	}
+
+	for _, chunk := range m.chunks {
+		target, ok := strategies.ResolveReference(location, "", chunk)
+		if !ok {
+			continue
+		}
+		// local builds often ship without some of the chunks they list
+		if !strategies.IsURL(target) {
+			if _, err := os.Stat(target); err != nil {
+				logger.Get().Debugf("%s: chunk not found file=%s", m.framework, target)
+				continue
+			}
+		}
+		if j.discovered(u, location, target, u.depth) {
+			logger.Get().Debugf("%s: queued chunk=%s", m.framework, target)
+		}
+	}
+}
+
+// parseManifest recognises a build manifest from its name and parses it.
+func parseManifest(location string, content []byte) (buildManifest, bool) {
+	p := location
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" {
+		p = u.Path
+	}
+	p = strings.ReplaceAll(p, "\\", "/")
+
+	switch name := path.Base(p); {
+	case name == "_buildManifest.js":
+		return parseNextBuildManifest(content), true
+	case name == "_ssgManifest.js":
+		return parseNextSSGManifest(content), true
+	case name == "manifest.json" || strings.HasSuffix(p, "/.vite/manifest.json"):
+		return parseViteManifest(p, content)
+	case strings.Contains("/"+p, "/_nuxt/builds/") && path.Ext(p) == ".json":
+		return parseNuxtBuild(name, content)
+	}
+	return buildManifest{}, false
+}
+
+// parseNextBuildManifest reads self.__BUILD_MANIFEST. Chunks are relative to
+// the _next directory, two levels above the manifest.
+func parseNextBuildManifest(content []byte) buildManifest {
+	text := unescapeSlashes(string(content))
+	m := buildManifest{framework: "next.js"}
+
+	var routes []string
+	for _, r := range nextRoutePattern.FindAllStringSubmatch(text, -1) {
+		routes = append(routes, r[1])
+	}
+	if sp := nextSortedPagesPattern.FindStringSubmatch(text); sp != nil {
+		for _, r := range quotedStringPattern.FindAllStringSubmatch(sp[1], -1) {
+			routes = append(routes, r[1])
+		}
+	}
+	m.routes = pageRoutes(routes)
+
+	for _, c := range nextChunkPattern.FindAllStringSubmatch(text, -1) {
+		m.chunks = append(m.chunks, "../../"+c[1])
+	}
+	m.chunks = unique(m.chunks)
+
+	return m
+}
+
+// parseNextSSGManifest reads the statically generated routes of
+// self.__SSG_MANIFEST.
+func parseNextSSGManifest(content []byte) buildManifest {
+	text := unescapeSlashes(string(content))
+	m := buildManifest{framework: "next.js"}
+
+	if set := nextSSGPattern.FindStringSubmatch(text); set != nil {
+		var routes []string
+		for _, r := range quotedStringPattern.FindAllStringSubmatch(set[1], -1) {
+			routes = append(routes, r[1])
+		}
+		m.routes = pageRoutes(routes)
+	}
+
+	return m
+}
+
+// parseViteManifest reads a Vite manifest. Files are relative to the build
+// directory, the parent of .vite for Vite 5 and the manifest's own directory
+// before that.
+func parseViteManifest(p string, content []byte) (buildManifest, bool) {
+	var chunks map[string]struct {
+		File string `json:"file"`
+	}
+	if err := json.Unmarshal(content, &chunks); err != nil {
+		return buildManifest{}, false
+	}
+
+	prefix := ""
+	if path.Base(path.Dir(p)) == ".vite" {
+		prefix = "../"
+	}
+
+	m := buildManifest{framework: "vite"}
+	for _, c := range chunks {
+		if c.File != "" && strategies.IsScript(c.File) {
+			m.chunks = append(m.chunks, prefix+c.File)
+		}
+	}
+	if len(m.chunks) == 0 {
+		return buildManifest{}, false
+	}
+
+	m.chunks = unique(m.chunks)
+	sort.Strings(m.chunks)
+	return m, true
+}
+
+// parseNuxtBuild reads the routes of _nuxt/builds/meta/<id>.json. The build
+// id of _nuxt/builds/latest.json is followed to its meta file.
+func parseNuxtBuild(name string, content []byte) (buildManifest, bool) {
+	var build struct {
+		ID          string                                `json:"id"`
+		Matcher     map[string]map[string]json.RawMessage `json:"matcher"`
+		Prerendered []string                              `json:"prerendered"`
+	}
+	if err := json.Unmarshal(content, &build); err != nil {
+		return buildManifest{}, false
+	}
+
+	m := buildManifest{framework: "nuxt"}
+	if name == "latest.json" {
+		if build.ID != "" {
+			m.chunks = []string{"meta/" + build.ID + ".json"}
+		}
+		return m, true
+	}
+
+	routes := build.Prerendered
+	for _, matcher := range build.Matcher {
+		for route := range matcher {
+			routes = append(routes, route)
+		}
+	}
+	m.routes = pageRoutes(routes)
+
+	return m, true
+}
+
+// pageRoutes drops internal pages like /_app and duplicates, and sorts the
+// routes.
+func pageRoutes(routes []string) []string {
+	var pages []string
+	for _, r := range routes {
+		if strings.HasPrefix(r, "/") && !strings.HasPrefix(r, "/_") {
+			pages = append(pages, r)
+		}
+	}
+	pages = unique(pages)
+	sort.Strings(pages)
+	return pages
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// unescapeSlashes undoes the \u002F escaping Next.js uses in its manifests.
+func unescapeSlashes(text string) string {
+	return strings.NewReplacer(`\u002F`, "/", `\u002f`, "/").Replace(text)
+}
@@ -0,0 +1,49 @@
+package scanner
+
+import (
+	"io/ioutil"
+
+	"github.com/riza/linx/internal/output"
+	"github.com/riza/linx/internal/scanner/strategies"
+	"github.com/riza/linx/pkg/logger"
+)
+
+// redirected records the redirects of the request made for location and
+// returns the URL they led to, or an empty string when there were none.
+func (s scanner) redirected(location string, redirects []string, j *job) string {
+	if len(redirects) == 0 {
+		return ""
+	}
+
+	final := redirects[len(redirects)-1]
+	j.out.Responses = append(j.out.Responses, output.Response{URL: location, Redirects: redirects})
+	logger.Get().Infof("redirected url=%s to=%s", location, final)
+	return final
+}
+
+// fetchPage reads the page of an HTML strategy when it is fetched over HTTP,
+// so its redirects are recorded and its scripts are resolved against the URL
+// it was redirected to.
+func (s scanner) fetchPage(hs strategies.HTMLStrategy, j *job) (strategies.HTMLStrategy, error) {
+	ps, ok := hs.Page.(strategies.URLStrategy)
+	if !ok {
+		return hs, nil
+	}
+
+	body, charset, err := ps.OpenContent()
+	if err != nil {
+		return hs, err
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return hs, err
+	}
+	if response, ok := body.(*strategies.ResponseBody); ok {
+		hs.URL = s.redirected(hs.Target, response.Redirects, j)
+	}
+
+	hs.Page = strategies.ContentStrategy{Name: hs.Target, Content: content, Charset: charset}
+	return hs, nil
+}
@@ -1,13 +1,20 @@
 package scanner
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"unsafe"
 
+	"github.com/riza/linx/internal/httpclient"
 	"github.com/riza/linx/internal/options"
 	"github.com/riza/linx/internal/output"
 	"github.com/riza/linx/internal/scanner/strategies"
@@ -36,6 +43,14 @@ const (
 	excludeFileTypeRule = `.css|.jpg|.jpeg|.png|.svg|.img|.gif|.mp4|.flv|.ogv|.webm|.webp|.mov|.mp3|.m4a|.m4p|.scss|.tif|.tiff|.ttf|.otf|.woff|.woff2|.bmp|.ico|.eot|.htc|.rtf|.swf|.image|w3.org|doubleclick.net|youtube.com|.vue|jquery|bootstrap|font|jsdelivr.net|vimeo.com|pinterest.com|facebook|linkedin|twitter|instagram|google|mozilla.org|jibe.com|schema.org|schemas.microsoft.com|wordpress.org|w.org|wix.com|parastorage.com|whatwg.org|polyfill.io|typekit.net|schemas.openxmlformats.org|openweathermap.org|openoffice.org|reactjs.org|angularjs.org|java.com|purl.org|/image|/img|/css|/wp-json|/wp-content|/wp-includes|/theme|/audio|/captcha|/font|robots.txt|node_modules|.wav|.gltf`
 
 	excludeMimeTypeRule = `text/css|image/jpeg|image/jpg|image/png|image/svg+xml|image/gif|image/tiff|image/webp|image/bmp|image/x-icon|image/vnd.microsoft.icon|font/ttf|font/woff|font/woff2|font/x-woff2|font/x-woff|font/otf|audio/mpeg|audio/wav|audio/webm|audio/aac|audio/ogg|audio/wav|audio/webm|video/mp4|video/mpeg|video/webm|video/ogg|video/mp2t|video/webm|video/x-msvideo|application/font-woff|application/font-woff2|application/vnd.android.package-archive|binary/octet-stream|application/octet-stream|application/pdf|application/x-font-ttf|application/x-font-otf|application/json|text/javascript|text/plain|text/x-yaml|text/html|text/babel|text/markdown|text/tsx|application/typescript|application/javascript|text/x-handlebars-template|application/x-typescript|text/x-gfm|text/jsx`
+
+	// scripts larger than a window are matched a window at a time, windows
+	// overlap so matches across their boundary are found
+	windowSize    = 8 << 20
+	windowOverlap = 64 << 10
+
+	// maxOutputNameLength keeps the per-target output file names short
+	maxOutputNameLength = 100
 )
 
 var (
@@ -45,6 +60,9 @@ var (
 		".json": output.OutputJSON{},
 	}
 
+	// characters replaced in the per-target output file names
+	unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
 	// Compile all rules at init
 	patterns []*regexp.Regexp
 )
@@ -66,6 +84,65 @@ type task struct {
 	strategy strategies.ScanStrategy
 }
 
+// unit is a single script of a target along with where it came from.
+type unit struct {
+	source   string
+	page     string
+	strategy strategies.ScanStrategy
+	// chain lists the scripts that led to this one when crawling
+	chain []string
+	depth int
+	// offline units come from recorded traffic, nothing they reference is
+	// fetched
+	offline bool
+	// base is the URL the source was redirected to, references found in it
+	// are resolved against it
+	base string
+}
+
+// job is the state of a target while it is scanned: the output being built
+// and the scripts discovered to be scanned next.
+type job struct {
+	out     *output.OutputData
+	visited map[string]bool
+	queue   []unit
+}
+
+// discovered queues a script found while scanning the parent unit at
+// location, unless it has been visited already.
+func (j *job) discovered(parent unit, location, target string, depth int) bool {
+	if j.visited[target] || !parent.canFetch(target) {
+		return false
+	}
+
+	j.visited[target] = true
+	j.queue = append(j.queue, unit{
+		source:   target,
+		page:     parent.page,
+		strategy: strategies.StrategyForReference(target),
+		chain:    append(append([]string{}, parent.chain...), location),
+		depth:    depth,
+		offline:  parent.offline,
+	})
+	return true
+}
+
+// failed records why the source could not be scanned, along with the
+// redirects that led to a redirect the policy did not follow.
+func (j *job) failed(source string, err error) {
+	failure := output.Failure{Source: source, Reason: err.Error()}
+	var redirect *strategies.RedirectError
+	if errors.As(err, &redirect) {
+		failure.Redirects = redirect.Redirects
+	}
+	j.out.Failures = append(j.out.Failures, failure)
+}
+
+// canFetch reports whether a reference found in the unit may be fetched.
+func (u unit) canFetch(target string) bool {
+	return !u.offline || !strategies.IsURL(target)
+}
+
 type scanner struct {
 	task task
 	opts *options.Options
@@ -73,11 +150,6 @@ type scanner struct {
 
 func NewScanner(opts *options.Options) scanner {
 	return scanner{
-		task: task{
-			target:   opts.Target,
-			output:   opts.Output,
-			strategy: defineStrategyForTarget(opts.Target),
-		},
 		opts: opts,
 	}
 }
@@ -86,53 +158,58 @@ func (s scanner) Run() error {
 	rFt, _ := regexp.Compile(excludeFileTypeRule)
 	rMt, _ := regexp.Compile(excludeMimeTypeRule)
 
-	// Multiple targets support
-	targets := strings.Split(s.task.target, ",")
+	// Targets are streamed from arguments, list file or stdin
+	targets := s.opts.Targets()
 
-	if len(targets) > 1 && s.opts.Parallel {
+	if s.opts.HasMultipleTargets() && s.opts.Parallel {
 		return s.runParallel(targets, rFt, rMt)
 	}
 
-	if len(targets) > 1 {
-		for _, target := range targets {
-			s.task.target = strings.TrimSpace(target)
-			s.task.strategy = defineStrategyForTarget(s.task.target)
-			if err := s.processTarget(rFt, rMt); err != nil {
-				logger.Get().Errorf("Error processing target %s: %v", target, err)
+	for target := range targets {
+		s.task = s.newTask(target)
+		if err := s.processTarget(rFt, rMt); err != nil {
+			if !s.opts.HasMultipleTargets() {
+				return err
 			}
+			logger.Get().Errorf("Error processing target %s: %v", target, err)
 		}
-		return nil
 	}
 
-	return s.processTarget(rFt, rMt)
+	return nil
 }
 
-func (s scanner) runParallel(targets []string, rFt, rMt *regexp.Regexp) error {
+func (s scanner) runParallel(targets <-chan string, rFt, rMt *regexp.Regexp) error {
 	var wg sync.WaitGroup
-	errChan := make(chan error, len(targets))
-
-	for _, target := range targets {
-		wg.Add(1)
-		go func(target string) {
-			defer wg.Done()
-
-			scannerCopy := scanner{
-				task: task{
-					target:   strings.TrimSpace(target),
-					output:   s.task.output + "." + filepath.Base(strings.TrimSpace(target)),
-					strategy: defineStrategyForTarget(strings.TrimSpace(target)),
-				},
-				opts: s.opts,
-			}
+	errChan := make(chan error)
 
-			if err := scannerCopy.processTarget(rFt, rMt); err != nil {
-				errChan <- fmt.Errorf("Error processing target %s: %v", target, err)
-			}
-		}(target)
+	workers := s.opts.Concurrency
+	if workers < 1 {
+		workers = 1
 	}
 
-	wg.Wait()
-	close(errChan)
+	// A fixed pool of workers takes targets as they are streamed
+	go func() {
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for target := range targets {
+					scannerCopy := scanner{
+						task: s.newTask(target),
+						opts: s.opts,
+					}
+
+					if err := scannerCopy.processTarget(rFt, rMt); err != nil {
+						errChan <- fmt.Errorf("Error processing target %s: %v", target, err)
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(errChan)
+	}()
 
 	// Report any errors
 	for err := range errChan {
@@ -142,25 +219,365 @@ func (s scanner) runParallel(targets []string, rFt, rMt *regexp.Regexp) error {
 	return nil
 }
 
-func (s scanner) processTarget(rFt, rMt *regexp.Regexp) error {
-	strategy := s.task.strategy
-	content, err := strategy.GetContent()
-	if err != nil {
-		return fmt.Errorf("error getting file content: %v", err)
+// newTask prepares a task for the target. When several targets are scanned,
+// each one gets its own output file named after the whole target, so targets
+// sharing a file name do not overwrite each other. The name goes before the
+// extension to keep the output engine selectable.
+func (s scanner) newTask(target string) task {
+	out := s.opts.Output
+	if out != "" && s.opts.HasMultipleTargets() {
+		ext := filepath.Ext(out)
+		out = strings.TrimSuffix(out, ext) + "." + outputName(target) + ext
+	}
+
+	// a Host header given in the options is sent to the target's host only
+	if strategies.IsURL(target) {
+		httpclient.Get().AddTarget(target)
+	}
+
+	return task{
+		target:   target,
+		output:   out,
+		strategy: s.defineStrategyForTarget(target),
+	}
+}
+
+// outputName turns a target into a file name: the target without its scheme,
+// with path separators and other unsafe characters replaced, shortened if it
+// is too long, and followed by a short hash of the target to keep it unique.
+func outputName(target string) string {
+	name := target
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name = unsafeNameChars.ReplaceAllString(strings.Trim(name, "/"), "_")
+	if len(name) > maxOutputNameLength {
+		name = name[len(name)-maxOutputNameLength:]
 	}
 
+	sum := sha256.Sum256([]byte(target))
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+func (s scanner) processTarget(rFt, rMt *regexp.Regexp) error {
 	out := &output.OutputData{
 		Target:   s.task.target,
 		Filename: s.task.output,
 		Results:  []output.Result{},
 	}
+	j := &job{
+		out:     out,
+		visited: map[string]bool{s.task.target: true},
+	}
+
+	// a target that fails is still saved, with the reason it failed
+	walkErr := s.walk(unit{strategy: s.task.strategy}, j, rFt, rMt)
+	if walkErr != nil {
+		j.failed(s.task.target, walkErr)
+	}
+
+	// Scan the scripts discovered along the way until none is left
+	for len(j.queue) > 0 {
+		u := j.queue[0]
+		j.queue = j.queue[1:]
+		if err := s.walk(u, j, rFt, rMt); err != nil {
+			logger.Get().Errorf("Error processing %s: %v", u.source, err)
+			j.failed(u.source, err)
+		}
+	}
+
+	logger.Get().Infof("%d possible url found", len(out.Results))
+	oE, ok := outputEngines[s.getOutputEngineKey()]
+	if !ok {
+		return fmt.Errorf("output engine not found: %s", s.getOutputEngineKey())
+	}
+
+	err := oE.RenderAndSave(out)
+	if err != nil {
+		return fmt.Errorf("output failed: %v", err)
+	}
+
+	return walkErr
+}
+
+// walk scans the strategy, or every script inside it when it holds more than
+// one. A script that fails is logged so the rest of the target is still scanned.
+func (s scanner) walk(u unit, j *job, rFt, rMt *regexp.Regexp) error {
+	ms, ok := u.strategy.(strategies.MultiStrategy)
+	if !ok {
+		return s.scan(u, j, rFt, rMt)
+	}
+
+	// scripts of an HTML page are attributed to it
+	page := u.page
+	if hs, ok := ms.(strategies.HTMLStrategy); ok {
+		page = hs.Target
+		fetched, err := s.fetchPage(hs, j)
+		if err != nil {
+			return err
+		}
+		ms = fetched
+	}
+
+	offline := u.offline
+	if _, ok := ms.(strategies.OfflineStrategy); ok {
+		offline = true
+	}
+
+	return ms.Walk(func(source string, strategy strategies.ScanStrategy) error {
+		// scripts already queued as chunks of another one are scanned once
+		if j.visited[source] {
+			logger.Get().Debugf("script is already visited, skipped source=%s", source)
+			return nil
+		}
+		j.visited[source] = true
+		child := unit{source: source, page: page, strategy: strategy, chain: u.chain, depth: u.depth, offline: offline}
+		if err := s.walk(child, j, rFt, rMt); err != nil {
+			logger.Get().Errorf("Error processing %s: %v", source, err)
+			j.failed(source, err)
+		}
+		return nil
+	})
+}
+
+// scan matches the patterns against the unit's content and appends the
+// results, attributed to its source and page, to the job's output. When the
+// content has a source map, its original sources are scanned instead.
+func (s scanner) scan(u unit, j *job, rFt, rMt *regexp.Regexp) error {
+	content, charset, rest, response, err := readContent(u.strategy)
+	var page *strategies.HTMLResponseError
+	if errors.As(err, &page) {
+		return s.htmlResponse(u, page, j, rFt, rMt)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting file content: %w", err)
+	}
+	if rest != nil {
+		defer rest.Close()
+	}
+	if response != nil {
+		u.base = s.redirected(s.location(u), response.Redirects, j)
+	}
+
+	start := len(j.out.Results)
+
+	// Hermes bytecode holds no JavaScript source to match
+	if version, ok := strategies.HermesVersion(content); ok {
+		j.out.Results = append(j.out.Results, output.Result{
+			URL:      s.location(u),
+			Location: fmt.Sprintf("hermes bytecode bundle version=%d, decompile it before scanning", version),
+			Kind:     output.KindHermes,
+			Source:   u.source,
+			Page:     u.page,
+			Chain:    u.chain,
+		})
+		logger.Get().Warnf("hermes bytecode bundle can not be scanned: %s", s.location(u))
+		return nil
+	}
+
+	if rest != nil {
+		first, last, err := s.scanStream(u, content, rest, charset, j, rFt, rMt)
+		if err != nil {
+			return err
+		}
+		s.streamed(u, first, last, j, rFt, rMt)
+		if s.opts.Crawl {
+			s.crawl(u, j.out.Results[start:], j)
+		}
+		return nil
+	}
+
+	// patterns are matched against UTF-8, offsets lead back to the original bytes
+	content, offsets, encoding := strategies.DecodeText(content, charset)
+	if offsets != nil {
+		logger.Get().Debugf("converted %s content to utf-8 source=%s", encoding, s.location(u))
+	}
+
+	var sm *sourceMap
+	if !s.opts.NoSourceMap {
+		sm = s.loadSourceMap(u, content, j)
+	}
+
+	switch {
+	case sm != nil && sm.hasSourcesContent():
+		// the bundle holds code that is not in the sources, like the runtime
+		// and inlined config, its urls not found in the sources are reported
+		w := newWindow(content, nil, offsets.Original)
+		w.seen = s.matchSources(u, sm, j, rFt, rMt)
+		s.match(u, w, j, rFt, rMt)
+	case sm != nil:
+		s.match(u, newWindow(content, sm.mappedOrigin(content), offsets.Original), j, rFt, rMt)
+	default:
+		s.match(u, newWindow(content, nil, offsets.Original), j, rFt, rMt)
+	}
+
+	s.manifests(u, content, j)
+	if !s.opts.NoChunks {
+		s.chunks(u, content, j)
+	}
+
+	if s.opts.Crawl {
+		s.crawl(u, j.out.Results[start:], j)
+	}
+
+	return nil
+}
+
+// scanStream matches the patterns against a script too large to be read
+// whole, one window at a time. Consecutive windows overlap so a match cut by
+// the end of a window is found in the next one; matches starting in the
+// overlap are left to it. The text of the first and last windows is returned.
+func (s scanner) scanStream(u unit, head []byte, rest io.Reader, charset string, j *job, rFt, rMt *regexp.Regexp) ([]byte, []byte, error) {
+	logger.Get().Warnf("script is larger than %dMB, it is matched in windows and only its first and last windows are searched for its source map and webpack chunks source=%s",
+		windowSize>>20, s.location(u))
+
+	decoder := strategies.NewTextDecoder(head, charset)
+	seen := make(map[string]bool)
+
+	raw := make([]byte, windowSize)
+	n := copy(raw, head)
+	raw = raw[:n]
+	// offset of the window in the content
+	base := 0
+	// the first window is copied, raw is reused for the next ones
+	var first []byte
+
+	for {
+		n, err := io.ReadFull(rest, raw[len(raw):windowSize])
+		raw = raw[:len(raw)+n]
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return nil, nil, fmt.Errorf("error reading file content: %v", err)
+		}
+
+		text, offsets, decoded := decoder.Decode(raw, base, eof)
+		if first == nil {
+			first = append([]byte{}, text...)
+		}
+		w := newWindow(text, nil, offsets.Original)
+		w.seen = seen
+		if !eof && len(text) > windowOverlap {
+			w.limit = len(text) - windowOverlap
+		}
+		s.match(u, w, j, rFt, rMt)
+
+		if eof {
+			return first, text, nil
+		}
 
+		// the next window starts with the character holding the limit
+		next := decoded
+		if w.limit < len(text) {
+			next = offsets.Original(w.limit) - base
+		}
+		raw = raw[:copy(raw, raw[next:])]
+		base += next
+	}
+}
+
+// streamed looks for the source map and the webpack chunks of a script
+// matched in windows. The sourceMappingURL comment ends a script and the
+// webpack runtime opens or ends it, so only the first and last windows are
+// searched. The sources embedded in the map are matched too.
+func (s scanner) streamed(u unit, first, last []byte, j *job, rFt, rMt *regexp.Regexp) {
+	if !s.opts.NoSourceMap {
+		if sm := s.loadSourceMap(u, last, j); sm != nil && sm.hasSourcesContent() {
+			s.matchSources(u, sm, j, rFt, rMt)
+		}
+	}
+
+	if !s.opts.NoChunks {
+		s.chunks(u, first, j)
+		s.chunks(u, last, j)
+	}
+}
+
+// matchSources matches the patterns against the original sources embedded
+// in a source map, attributed to their own files and lines. The urls found
+// are returned.
+func (s scanner) matchSources(u unit, sm *sourceMap, j *job, rFt, rMt *regexp.Regexp) map[string]bool {
+	found := make(map[string]bool)
+	for i, src := range sm.SourcesContent {
+		if src == nil {
+			continue
+		}
+		original := []byte(*src)
+		w := newWindow(original, sm.fileOrigin(i, original), nil)
+		s.match(u, w, j, rFt, rMt)
+		for url := range w.seen {
+			found[url] = true
+		}
+	}
+	return found
+}
+
+// readContent reads the content of a strategy along with its declared
+// charset. When a streamed script is larger than a window only the first
+// window is read, the rest is returned to be scanned as a stream. The
+// response is returned too when the content was fetched over HTTP.
+func readContent(strategy strategies.ScanStrategy) ([]byte, string, io.ReadCloser, *strategies.ResponseBody, error) {
+	ss, ok := strategy.(strategies.StreamStrategy)
+	if !ok {
+		content, charset, err := strategies.GetEncodedContent(strategy)
+		return content, charset, nil, nil, err
+	}
+
+	rest, charset, err := ss.OpenContent()
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	response, _ := rest.(*strategies.ResponseBody)
+
+	head, err := ioutil.ReadAll(io.LimitReader(rest, windowSize))
+	if err != nil || len(head) < windowSize {
+		rest.Close()
+		return head, charset, nil, response, err
+	}
+	return head, charset, rest, response, nil
+}
+
+// origin maps a match in the scanned content back to the original file and
+// line, along with the context to report for it.
+type origin func(start, end int) (file string, line int, context string)
+
+// window is content matched at once along with how its matches are located.
+type window struct {
+	content []byte
+	// matches starting at limit or after are left to the next window
+	limit int
+	orig  origin
+	// offset maps a match to its offset in the source, it is nil when the
+	// content is not the source's own
+	offset func(int) int
+	// seen holds the urls already reported for the source
+	seen map[string]bool
+}
+
+func newWindow(content []byte, orig origin, offset func(int) int) window {
+	return window{
+		content: content,
+		limit:   len(content),
+		orig:    orig,
+		offset:  offset,
+		seen:    make(map[string]bool),
+	}
+}
+
+// match applies the patterns to the window and appends the possible urls to
+// the job's output. Without an origin the context is taken around the match.
+func (s scanner) match(u unit, w window, j *job, rFt, rMt *regexp.Regexp) {
+	out := j.out
+
+	content := w.content
 	contentStr := *(*string)(unsafe.Pointer(&content))
-	processedUrls := make(map[string]bool)
+	processedUrls := w.seen
 
 	// Apply each pattern to find URLs
 	for _, pattern := range patterns {
 		for _, match := range pattern.FindAllStringSubmatchIndex(contentStr, -1) {
+			if match[0] >= w.limit {
+				continue
+			}
 			fullMatch := contentStr[match[0]:match[1]]
 
 			// Extract URL from the full match - different patterns may have different group indices
@@ -199,6 +616,9 @@ func (s scanner) processTarget(rFt, rMt *regexp.Regexp) error {
 			if _, exists := processedUrls[url]; exists {
 				continue
 			}
+			// the url shares its bytes with the window, which is reused for
+			// the next one, so it is copied out
+			url = string([]byte(url))
 			processedUrls[url] = true
 
 			// Limit the context to avoid huge outputs
@@ -213,27 +633,29 @@ func (s scanner) processTarget(rFt, rMt *regexp.Regexp) error {
 			}
 
 			closeLines := content[startIdx:endIdx]
-			out.Results = append(out.Results, output.Result{
+			result := output.Result{
 				URL:      url,
 				Location: string(closeLines),
-			})
+				Source:   u.source,
+				Page:     u.page,
+				Chain:    u.chain,
+			}
+			if w.offset != nil {
+				offset := w.offset(match[0])
+				result.Offset = &offset
+			}
+			if w.orig != nil {
+				file, line, context := w.orig(match[0], match[1])
+				result.File, result.Line = file, line
+				if context != "" {
+					result.Location = context
+				}
+			}
+			out.Results = append(out.Results, result)
 
 			logger.Get().Infof("found possible url: %s", url)
 		}
 	}
-
-	logger.Get().Infof("%d possible url found", len(out.Results))
-	oE, ok := outputEngines[s.getOutputEngineKey()]
-	if !ok {
-		return fmt.Errorf("output engine not found: %s", s.getOutputEngineKey())
-	}
-
-	err = oE.RenderAndSave(out)
-	if err != nil {
-		return fmt.Errorf("output failed: %v", err)
-	}
-
-	return nil
 }
 
 // cleanUrl removes common noise in URLs
@@ -267,9 +689,52 @@ func (s scanner) isAlreadyExists(url string, out *output.OutputData) bool {
 	return false
 }
 
-func defineStrategyForTarget(target string) strategies.ScanStrategy {
+func (s scanner) defineStrategyForTarget(target string) strategies.ScanStrategy {
+	if target == options.StdinTarget {
+		return strategies.StdinStrategy{}
+	}
 	if strings.Contains(target, "http://") || strings.Contains(target, "https://") {
+		if strategies.IsHTML(target) {
+			return strategies.HTMLStrategy{Target: target, Page: strategies.URLStrategy{Target: target}}
+		}
 		return strategies.URLStrategy{Target: target}
 	}
-	return strategies.FileStrategy{Target: target}
+	if strategies.IsWARC(target) {
+		return strategies.WARCStrategy{Target: target}
+	}
+	if strategies.IsAsar(target) {
+		return strategies.AsarStrategy{
+			Target:       target,
+			MaxDepth:     s.opts.MaxArchiveDepth,
+			MaxEntrySize: int64(s.opts.MaxEntrySize) << 20,
+		}
+	}
+	if strategies.IsArchive(target) {
+		return strategies.ArchiveStrategy{
+			Target:       target,
+			MaxDepth:     s.opts.MaxArchiveDepth,
+			MaxEntrySize: int64(s.opts.MaxEntrySize) << 20,
+		}
+	}
+	switch strings.ToLower(filepath.Ext(target)) {
+	case ".har":
+		return strategies.HARStrategy{Target: target}
+	case ".xml":
+		return strategies.BurpStrategy{Target: target}
+	}
+	if strategies.IsRawRequest(target) {
+		return strategies.RequestStrategy{Target: target, BaseURL: s.opts.RequestBase}
+	}
+	if stat, err := os.Stat(target); (err != nil && strategies.IsGlob(target)) || (err == nil && stat.IsDir()) {
+		return strategies.DirStrategy{
+			Target:          target,
+			Include:         s.opts.Include,
+			Exclude:         s.opts.Exclude,
+			SkipNodeModules: s.opts.SkipNodeModules,
+		}
+	}
+	if strategies.IsHTML(target) {
+		return strategies.HTMLStrategy{Target: target, Page: strategies.FileStrategy{Target: target}}
+	}
+	return strategies.FileStrategy{Target: target, MaxSize: int64(s.opts.MaxEntrySize) << 20}
 }
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"regexp"
+
+	"github.com/riza/linx/internal/options"
+	"github.com/riza/linx/internal/output"
+	"github.com/riza/linx/internal/scanner/strategies"
+	"github.com/riza/linx/pkg/logger"
+)
+
+// htmlResponse handles an HTML page returned for a URL. A page returned for
+// a URL without script extension has its scripts scanned. One returned for a
+// script URL is handled as the soft-404 option says, and the decision is
+// recorded in the output. By default it is skipped, since matching it as a
+// script only reports the links of the page.
+func (s scanner) htmlResponse(u unit, page *strategies.HTMLResponseError, j *job, rFt, rMt *regexp.Regexp) error {
+	location := s.location(u)
+	content := strategies.ContentStrategy{Name: location, Content: page.Content, Charset: page.Charset}
+
+	if !strategies.IsScriptURL(location) {
+		u.strategy = strategies.HTMLStrategy{
+			Target: location,
+			URL:    s.redirected(location, page.Redirects, j),
+			Page:   content,
+		}
+		return s.walk(u, j, rFt, rMt)
+	}
+
+	response := output.Response{URL: location, Redirects: page.Redirects, ContentType: page.ContentType}
+
+	// the page is resolved against the URL it was redirected to
+	base := ""
+	if len(page.Redirects) > 0 {
+		base = page.Redirects[len(page.Redirects)-1]
+	}
+
+	switch s.opts.Soft404 {
+	case options.Soft404HTML:
+		response.Decision = output.DecisionHTML
+		j.out.Responses = append(j.out.Responses, response)
+		logger.Get().Infof("html page returned for a script, scanning its scripts url=%s", location)
+
+		hs := strategies.HTMLStrategy{Target: location, URL: base, Page: content}
+		return hs.Walk(func(source string, strategy strategies.ScanStrategy) error {
+			// the pages returned for missing scripts are the same one, its
+			// scripts are scanned once
+			if j.visited[source] {
+				return nil
+			}
+			j.visited[source] = true
+
+			child := unit{source: source, page: location, strategy: strategy, chain: u.chain, depth: u.depth, offline: u.offline}
+			if err := s.walk(child, j, rFt, rMt); err != nil {
+				logger.Get().Errorf("Error processing %s: %v", source, err)
+				j.failed(source, err)
+			}
+			return nil
+		})
+
+	case options.Soft404Scan:
+		response.Decision = output.DecisionScanned
+		j.out.Responses = append(j.out.Responses, response)
+		logger.Get().Warnf("html page returned for a script, scanning it as a script url=%s", location)
+
+		u.strategy, u.base = content, base
+		return s.scan(u, j, rFt, rMt)
+	}
+
+	response.Decision = output.DecisionSoft404
+	j.out.Responses = append(j.out.Responses, response)
+	logger.Get().Warnf("html page returned for a script, skipped as a soft 404 url=%s", location)
+	return nil
+}
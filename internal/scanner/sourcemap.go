@@ -0,0 +1,324 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/riza/linx/internal/output"
+	"github.com/riza/linx/internal/scanner/strategies"
+	"github.com/riza/linx/pkg/logger"
+)
+
+const (
+	sourceMappingURLRule = `(?m)(?://|/\*)[#@][ \t]*sourceMappingURL=([^\s'"*]+)`
+
+	base64VLQChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+	// maximum context reported from an original source line
+	maxContextLength = 300
+)
+
+var sourceMappingURLPattern = regexp.MustCompile(sourceMappingURLRule)
+
+// sourceMap is a version 3 source map.
+type sourceMap struct {
+	Version        int       `json:"version"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+	Mappings       string    `json:"mappings"`
+
+	// decoded mappings, one list of segments per generated line
+	lines [][]mapping
+}
+
+// mapping is a decoded segment of the mappings field.
+type mapping struct {
+	column       int
+	source       int
+	originalLine int
+}
+
+// loadSourceMap loads the source map of the unit from its sourceMappingURL
+// comment, or from a sibling .map file. Source maps reachable over HTTP are
+// reported as findings.
+func (s scanner) loadSourceMap(u unit, content []byte, j *job) *sourceMap {
+	location := s.location(u)
+
+	var ref string
+	if m := sourceMappingURLPattern.FindAllSubmatch(content, -1); len(m) > 0 {
+		ref = string(m[len(m)-1][1])
+	}
+
+	var data []byte
+	var err error
+	mapURL := ""
+
+	switch {
+	case strings.HasPrefix(ref, "data:"):
+		data, err = decodeDataURI(ref)
+	case ref != "":
+		target, ok := strategies.ResolveReference(location, "", ref)
+		if !ok || !u.canFetch(target) {
+			return nil
+		}
+		mapURL = target
+		data, err = strategies.StrategyForReference(target).GetContent()
+	default:
+		target, ok := siblingSourceMap(location)
+		if !ok || !u.canFetch(target) {
+			return nil
+		}
+		mapURL = target
+		data, err = strategies.StrategyForReference(target).GetContent()
+		// a missing sibling is the usual case, single page apps answer it
+		// with their index.html
+		if err != nil || !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			logger.Get().Debugf("no source map found url=%s", target)
+			return nil
+		}
+	}
+
+	if err != nil {
+		logger.Get().Warnf("source map can not be loaded source=%s err: %v", location, err)
+		return nil
+	}
+
+	sm := &sourceMap{}
+	if err := json.Unmarshal(data, sm); err != nil || sm.Version != 3 {
+		logger.Get().Warnf("invalid source map url=%s", mapURL)
+		return nil
+	}
+	if err := sm.decode(); err != nil {
+		logger.Get().Warnf("invalid source map mappings url=%s err: %v", mapURL, err)
+		return nil
+	}
+
+	logger.Get().Debugf("loaded source map with %d sources url=%s", len(sm.Sources), mapURL)
+	if strategies.IsURL(mapURL) {
+		j.out.Results = append(j.out.Results, output.Result{
+			URL:      mapURL,
+			Location: fmt.Sprintf("publicly exposed source map with %d sources", len(sm.Sources)),
+			Kind:     output.KindSourceMap,
+			Source:   u.source,
+			Page:     u.page,
+			Chain:    u.chain,
+		})
+		logger.Get().Infof("found exposed source map: %s", mapURL)
+	}
+
+	return sm
+}
+
+// siblingSourceMap returns the .map file next to a script, if there may be
+// one. Local files are only returned when they exist. The map of a
+// pre-compressed app.js.gz is app.js.map.
+func siblingSourceMap(location string) (string, bool) {
+	if strategies.IsURL(location) {
+		u, err := url.Parse(location)
+		if err != nil || !strategies.IsScript(u.Path) {
+			return "", false
+		}
+		u.Path = strategies.TrimCompression(u.Path) + ".map"
+		u.RawQuery, u.Fragment = "", ""
+		return u.String(), true
+	}
+
+	if !strategies.IsScript(location) {
+		return "", false
+	}
+	mapFile := strategies.TrimCompression(location) + ".map"
+	if _, err := os.Stat(mapFile); err != nil {
+		return "", false
+	}
+	return mapFile, true
+}
+
+func decodeDataURI(uri string) ([]byte, error) {
+	comma := strings.Index(uri, ",")
+	if comma < 0 {
+		return nil, fmt.Errorf("invalid data uri")
+	}
+
+	meta, data := uri[:comma], uri[comma+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	decoded, err := url.PathUnescape(data)
+	return []byte(decoded), err
+}
+
+func (sm *sourceMap) hasSourcesContent() bool {
+	for _, src := range sm.SourcesContent {
+		if src != nil && *src != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sourcePath is the path of the i-th original source, with the source root.
+func (sm *sourceMap) sourcePath(i int) string {
+	if i < 0 || i >= len(sm.Sources) {
+		return ""
+	}
+	if sm.SourceRoot == "" {
+		return sm.Sources[i]
+	}
+	return path.Join(sm.SourceRoot, sm.Sources[i])
+}
+
+// fileOrigin attributes matches in the content of the i-th original source
+// to its own lines.
+func (sm *sourceMap) fileOrigin(i int, content []byte) origin {
+	starts := lineStarts(content)
+	file := sm.sourcePath(i)
+
+	return func(start, end int) (string, int, string) {
+		line := lineAt(starts, start)
+		return file, line + 1, lineText(content, starts, line)
+	}
+}
+
+// mappedOrigin attributes matches in the generated content through the
+// mappings, for source maps that do not embed their sources.
+func (sm *sourceMap) mappedOrigin(content []byte) origin {
+	starts := lineStarts(content)
+
+	return func(start, end int) (string, int, string) {
+		line := lineAt(starts, start)
+		m, ok := sm.lookup(line, start-starts[line])
+		if !ok {
+			return "", 0, ""
+		}
+		return sm.sourcePath(m.source), m.originalLine + 1, ""
+	}
+}
+
+// lookup finds the segment covering the generated line and column.
+func (sm *sourceMap) lookup(line, column int) (mapping, bool) {
+	if line >= len(sm.lines) {
+		return mapping{}, false
+	}
+
+	segments := sm.lines[line]
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].column > column
+	})
+	if i == 0 {
+		return mapping{}, false
+	}
+	return segments[i-1], true
+}
+
+// decode decodes the base64 VLQ mappings. Only segments that point to an
+// original source are kept.
+func (sm *sourceMap) decode() error {
+	var source, originalLine int
+
+	for _, line := range strings.Split(sm.Mappings, ";") {
+		var segments []mapping
+		column := 0
+
+		for _, segment := range strings.Split(line, ",") {
+			if segment == "" {
+				continue
+			}
+
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return err
+			}
+
+			column += fields[0]
+			if len(fields) < 4 {
+				continue
+			}
+			source += fields[1]
+			originalLine += fields[2]
+
+			segments = append(segments, mapping{
+				column:       column,
+				source:       source,
+				originalLine: originalLine,
+			})
+		}
+
+		sort.SliceStable(segments, func(a, b int) bool {
+			return segments[a].column < segments[b].column
+		})
+		sm.lines = append(sm.lines, segments)
+	}
+
+	return nil
+}
+
+func decodeVLQ(segment string) ([]int, error) {
+	var fields []int
+	value, shift := 0, 0
+
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(base64VLQChars, segment[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base64 vlq character %q", segment[i])
+		}
+
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+
+		if value&1 == 1 {
+			fields = append(fields, -(value >> 1))
+		} else {
+			fields = append(fields, value>>1)
+		}
+		value, shift = 0, 0
+	}
+
+	if shift != 0 {
+		return nil, fmt.Errorf("truncated base64 vlq segment %q", segment)
+	}
+	return fields, nil
+}
+
+// lineStarts returns the offset of the first byte of every line.
+func lineStarts(content []byte) []int {
+	starts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// lineAt returns the zero based line holding the offset.
+func lineAt(starts []int, offset int) int {
+	return sort.Search(len(starts), func(i int) bool {
+		return starts[i] > offset
+	}) - 1
+}
+
+func lineText(content []byte, starts []int, line int) string {
+	end := len(content)
+	if line+1 < len(starts) {
+		end = starts[line+1]
+	}
+
+	text := strings.TrimSpace(string(content[starts[line]:end]))
+	if len(text) > maxContextLength {
+		text = text[:maxContextLength]
+	}
+	return text
+}
@@ -0,0 +1,271 @@
+package strategies
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+const (
+	// DefaultMaxEntrySize is the default size limit of an archive entry
+	DefaultMaxEntrySize = 50 << 20
+	// DefaultMaxArchiveDepth is the default nesting limit of archives
+	DefaultMaxArchiveDepth = 3
+)
+
+// ArchiveExtensions are the file extensions opened as archives.
+var ArchiveExtensions = []string{".zip", ".tar", ".tgz", ".tar.gz", ".xpi", ".crx", ".jar", ".war", ".apk", ".apks", ".xapk", ".ipa"}
+
+// ArchiveStrategy opens a zip, tar, tar.gz or crx archive in memory and scans
+// its script, React Native bundle and HTML entries, named
+// archive!path/inside.js. APK and IPA files are zip archives too. Nested archives
+// are opened too, up to MaxDepth levels. Entries larger than MaxEntrySize
+// are skipped so decompression bombs can not exhaust memory.
+type ArchiveStrategy struct {
+	Target string
+	// Content is the archive itself, read from Target when nil
+	Content      []byte
+	Depth        int
+	MaxDepth     int
+	MaxEntrySize int64
+}
+
+func (as ArchiveStrategy) GetContent() ([]byte, error) {
+	if as.Content != nil {
+		return as.Content, nil
+	}
+	// a gzipped archive is read as is, walkContent decompresses it entry by
+	// entry within the size limit
+	return ioutil.ReadFile(as.Target)
+}
+
+func (as ArchiveStrategy) GetFileName() string {
+	return as.Target
+}
+
+func (as ArchiveStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected archive strategy target=%s depth=%d", as.Target, as.Depth)
+
+	content, err := as.GetContent()
+	if err != nil {
+		return err
+	}
+
+	return as.walkContent(content, fn)
+}
+
+func (as ArchiveStrategy) walkContent(content []byte, fn func(source string, strategy ScanStrategy) error) error {
+	switch {
+	case bytes.HasPrefix(content, []byte("Cr24")):
+		zipContent, err := crxZip(content)
+		if err != nil {
+			return err
+		}
+		return as.walkZip(zipContent, fn)
+
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")) || bytes.HasPrefix(content, []byte("PK\x05\x06")):
+		return as.walkZip(content, fn)
+
+	case bytes.HasPrefix(content, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		// a gzipped tar is streamed, otherwise it is a single gzipped file
+		br := bufio.NewReaderSize(gz, 1024)
+		if head, _ := br.Peek(512); isTar(head) {
+			return as.walkTar(br, fn)
+		}
+		inner, err := as.readEntry(as.Target, br)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(path.Base(as.Target), ".gz")
+		return as.entry(name, inner, fn)
+
+	case isTar(content):
+		return as.walkTar(bytes.NewReader(content), fn)
+	}
+
+	return fmt.Errorf("unsupported archive format target=%s", as.Target)
+}
+
+func (as ArchiveStrategy) walkZip(content []byte, fn func(source string, strategy ScanStrategy) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !as.wanted(f.Name) {
+			continue
+		}
+		if int64(f.UncompressedSize64) > as.maxEntrySize() {
+			logger.Get().Warnf("archive entry is too large, skipped entry=%s!%s size=%d", as.Target, f.Name, f.UncompressedSize64)
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			logger.Get().Warnf("archive entry can not be opened entry=%s!%s err: %v", as.Target, f.Name, err)
+			continue
+		}
+		data, err := as.readEntry(as.Target+"!"+f.Name, rc)
+		rc.Close()
+		if err != nil {
+			logger.Get().Warn(err)
+			continue
+		}
+
+		if err := as.entry(f.Name, data, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (as ArchiveStrategy) walkTar(r io.Reader, fn func(source string, strategy ScanStrategy) error) error {
+	tr := tar.NewReader(r)
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if h.Typeflag != tar.TypeReg || !as.wanted(h.Name) {
+			continue
+		}
+		if h.Size > as.maxEntrySize() {
+			logger.Get().Warnf("archive entry is too large, skipped entry=%s!%s size=%d", as.Target, h.Name, h.Size)
+			continue
+		}
+
+		data, err := as.readEntry(as.Target+"!"+h.Name, tr)
+		if err != nil {
+			logger.Get().Warn(err)
+			continue
+		}
+
+		if err := as.entry(h.Name, data, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// entry calls fn for an archive entry: nested archives are walked, scripts
+// scanned and HTML pages scanned for their inline scripts.
+func (as ArchiveStrategy) entry(name string, data []byte, fn func(source string, strategy ScanStrategy) error) error {
+	source := as.Target + "!" + strings.TrimPrefix(name, "./")
+
+	switch {
+	case IsArchive(name):
+		if as.Depth+1 > as.maxDepth() {
+			logger.Get().Warnf("archive nesting is too deep, skipped entry=%s", source)
+			return nil
+		}
+		nested := as
+		nested.Target, nested.Content, nested.Depth = source, data, as.Depth+1
+		return fn(source, nested)
+	case IsHTML(name):
+		return fn(source, HTMLStrategy{Target: source, Page: ContentStrategy{Name: source, Content: data}, InlineOnly: true})
+	}
+
+	if encoding := CompressionOf(name); encoding != "" {
+		decoded, err := decompress(source, encoding, data, as.maxEntrySize())
+		if err != nil {
+			logger.Get().Warnf("archive entry can not be decompressed entry=%s err: %v", source, err)
+			return nil
+		}
+		data = decoded
+	}
+	return fn(source, ContentStrategy{Name: source, Content: data})
+}
+
+// wanted reports whether an entry is scanned or opened.
+func (as ArchiveStrategy) wanted(name string) bool {
+	return IsScript(name) || IsReactNativeBundle(name) || IsHTML(name) || IsArchive(name)
+}
+
+// readEntry reads an entry, failing when it is larger than the size limit.
+func (as ArchiveStrategy) readEntry(name string, r io.Reader) ([]byte, error) {
+	limit := as.maxEntrySize()
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("archive entry can not be read entry=%s err: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("archive entry is too large, skipped entry=%s limit=%d", name, limit)
+	}
+	return data, nil
+}
+
+func (as ArchiveStrategy) maxEntrySize() int64 {
+	if as.MaxEntrySize <= 0 {
+		return DefaultMaxEntrySize
+	}
+	return as.MaxEntrySize
+}
+
+func (as ArchiveStrategy) maxDepth() int {
+	if as.MaxDepth <= 0 {
+		return DefaultMaxArchiveDepth
+	}
+	return as.MaxDepth
+}
+
+// crxZip returns the zip archive inside a Chrome extension package.
+func crxZip(content []byte) ([]byte, error) {
+	if len(content) < 12 {
+		return nil, fmt.Errorf("invalid crx header")
+	}
+
+	var offset uint64
+	switch version := binary.LittleEndian.Uint32(content[4:8]); version {
+	case 2:
+		if len(content) < 16 {
+			return nil, fmt.Errorf("invalid crx header")
+		}
+		offset = 16 + uint64(binary.LittleEndian.Uint32(content[8:12])) + uint64(binary.LittleEndian.Uint32(content[12:16]))
+	case 3:
+		offset = 12 + uint64(binary.LittleEndian.Uint32(content[8:12]))
+	default:
+		return nil, fmt.Errorf("unsupported crx version=%d", version)
+	}
+
+	if offset > uint64(len(content)) {
+		return nil, fmt.Errorf("invalid crx header")
+	}
+	return content[offset:], nil
+}
+
+func isTar(content []byte) bool {
+	return len(content) > 262 && bytes.Equal(content[257:262], []byte("ustar"))
+}
+
+// IsArchive reports whether the file name has one of the archive extensions.
+func IsArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range ArchiveExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
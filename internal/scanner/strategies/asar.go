@@ -0,0 +1,170 @@
+package strategies
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// AsarStrategy scans the JavaScript and HTML files of an Electron .asar
+// archive, named app.asar!path/inside.js. Files are read from the archive by
+// offset, or from the app.asar.unpacked directory next to it when they are
+// unpacked.
+type AsarStrategy struct {
+	Target       string
+	MaxDepth     int
+	MaxEntrySize int64
+}
+
+// asarEntry is a node of the asar header, a directory when Files is set.
+type asarEntry struct {
+	Files    map[string]asarEntry `json:"files"`
+	Size     int64                `json:"size"`
+	Offset   string               `json:"offset"`
+	Unpacked bool                 `json:"unpacked"`
+	Link     string               `json:"link"`
+}
+
+func (as AsarStrategy) GetContent() ([]byte, error) {
+	return nil, fmt.Errorf("%s is an asar archive, its files must be walked", as.Target)
+}
+
+func (as AsarStrategy) GetFileName() string {
+	return as.Target
+}
+
+func (as AsarStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected asar strategy target=%s", as.Target)
+
+	f, err := os.Open(as.Target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	root, base, err := readAsarHeader(f, stat.Size())
+	if err != nil {
+		return fmt.Errorf("invalid asar archive target=%s err: %w", as.Target, err)
+	}
+
+	return as.walkEntry(f, base, "", root, fn)
+}
+
+func (as AsarStrategy) walkEntry(f *os.File, base int64, name string, entry asarEntry, fn func(source string, strategy ScanStrategy) error) error {
+	if entry.Files != nil {
+		names := make([]string, 0, len(entry.Files))
+		for n := range entry.Files {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		for _, n := range names {
+			if err := as.walkEntry(f, base, path.Join(name, n), entry.Files[n], fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	archive := ArchiveStrategy{Target: as.Target, MaxDepth: as.MaxDepth, MaxEntrySize: as.MaxEntrySize}
+	if entry.Link != "" || !archive.wanted(name) {
+		return nil
+	}
+
+	source := as.Target + "!" + name
+	if entry.Size > archive.maxEntrySize() {
+		logger.Get().Warnf("asar entry is too large, skipped entry=%s size=%d", source, entry.Size)
+		return nil
+	}
+
+	data, err := as.readEntry(f, base, name, entry)
+	if err != nil {
+		logger.Get().Warnf("asar entry can not be read entry=%s err: %v", source, err)
+		return nil
+	}
+
+	return archive.entry(name, data, fn)
+}
+
+func (as AsarStrategy) readEntry(f *os.File, base int64, name string, entry asarEntry) ([]byte, error) {
+	if entry.Unpacked {
+		// names come from the header, they must stay in the unpacked directory
+		dir := filepath.Clean(as.Target + ".unpacked")
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			return nil, fmt.Errorf("unpacked entry is outside of the unpacked directory name=%s", name)
+		}
+		return ioutil.ReadFile(p)
+	}
+
+	offset, err := strconv.ParseInt(entry.Offset, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if entry.Size < 0 || offset < 0 || base+offset > stat.Size()-entry.Size {
+		return nil, fmt.Errorf("entry is outside of the archive offset=%d size=%d", offset, entry.Size)
+	}
+
+	data := make([]byte, entry.Size)
+	if _, err := f.ReadAt(data, base+offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readAsarHeader reads the pickled JSON header of an asar archive of size
+// bytes and returns it along with the offset files are relative to.
+func readAsarHeader(r io.Reader, size int64) (asarEntry, int64, error) {
+	var root asarEntry
+
+	// size pickle: payload size (always 4) and header pickle size
+	prefix := make([]byte, 16)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return root, 0, err
+	}
+	if binary.LittleEndian.Uint32(prefix[0:4]) != 4 {
+		return root, 0, fmt.Errorf("unexpected size pickle")
+	}
+
+	headerSize := int64(binary.LittleEndian.Uint32(prefix[4:8]))
+	jsonSize := int64(binary.LittleEndian.Uint32(prefix[12:16]))
+	// sizes are checked against the file before the header is allocated
+	if jsonSize > headerSize || 8+headerSize > size {
+		return root, 0, fmt.Errorf("invalid header size header=%d json=%d file=%d", headerSize, jsonSize, size)
+	}
+
+	header := make([]byte, jsonSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return root, 0, err
+	}
+	if err := json.Unmarshal(header, &root); err != nil {
+		return root, 0, err
+	}
+
+	return root, 8 + headerSize, nil
+}
+
+// IsAsar reports whether the file name is an Electron asar archive.
+func IsAsar(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".asar")
+}
@@ -0,0 +1,127 @@
+package strategies
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// BurpStrategy scans the JavaScript responses of a Burp Suite "Save items"
+// XML export, and the inline scripts of its HTML responses, with the request
+// URL as the source.
+type BurpStrategy struct {
+	Target string
+}
+
+type burpItem struct {
+	URL      string `xml:"url"`
+	MimeType string `xml:"mimetype"`
+	Response struct {
+		Base64 bool   `xml:"base64,attr"`
+		Data   string `xml:",chardata"`
+	} `xml:"response"`
+}
+
+func (bs BurpStrategy) GetContent() ([]byte, error) {
+	return FileStrategy{Target: bs.Target}.GetContent()
+}
+
+func (bs BurpStrategy) GetFileName() string {
+	return bs.Target
+}
+
+func (bs BurpStrategy) Offline() {}
+
+func (bs BurpStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected burp strategy target=%s", bs.Target)
+
+	f, err := os.Open(bs.Target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// items are decoded one by one to keep large exports out of memory
+	d := xml.NewDecoder(f)
+	d.Strict = false
+
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "item" {
+			continue
+		}
+
+		var item burpItem
+		if err := d.DecodeElement(&item, &start); err != nil {
+			return err
+		}
+
+		strategy, ok := bs.itemStrategy(item)
+		if !ok {
+			continue
+		}
+
+		if err := fn(item.URL, strategy); err != nil {
+			return err
+		}
+	}
+}
+
+// itemStrategy parses the raw HTTP response of an item and returns the
+// strategy to scan its body.
+func (bs BurpStrategy) itemStrategy(item burpItem) (ScanStrategy, bool) {
+	raw := []byte(item.Response.Data)
+	if item.Response.Base64 {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(item.Response.Data))
+		if err != nil {
+			logger.Get().Warnf("burp response can not be decoded url=%s err: %v", item.URL, err)
+			return nil, false
+		}
+		raw = decoded
+	}
+	if len(raw) == 0 {
+		return nil, false
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), nil)
+	if err != nil {
+		logger.Get().Warnf("burp response can not be parsed url=%s err: %v", item.URL, err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil && len(body) == 0 {
+		logger.Get().Warnf("burp response body can not be read url=%s err: %v", item.URL, err)
+		return nil, false
+	}
+
+	body, err = decompress(item.URL, resp.Header.Get("Content-Encoding"), body, DefaultMaxEntrySize)
+	if err != nil {
+		logger.Get().Warnf("burp response body can not be decoded url=%s err: %v", item.URL, err)
+		return nil, false
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" && strings.EqualFold(item.MimeType, "script") {
+		contentType = "application/javascript"
+	}
+
+	return recordedStrategy(item.URL, contentType, body)
+}
@@ -0,0 +1,264 @@
+package strategies
+
+import (
+	"bytes"
+	"mime"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/riza/linx/pkg/logger"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// sniffLength is how many bytes are looked at to tell UTF-16 without a byte
+// order mark
+const sniffLength = 1024
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// EncodedStrategy is implemented by strategies that know the charset of
+// their content, like the one of an HTTP response.
+type EncodedStrategy interface {
+	ScanStrategy
+	GetEncodedContent() (content []byte, charset string, err error)
+}
+
+// GetEncodedContent returns the content of the strategy along with its
+// declared charset, empty when it is not known.
+func GetEncodedContent(s ScanStrategy) ([]byte, string, error) {
+	if es, ok := s.(EncodedStrategy); ok {
+		return es.GetEncodedContent()
+	}
+	content, err := s.GetContent()
+	return content, "", err
+}
+
+// charsetOf returns the charset parameter of a Content-Type.
+func charsetOf(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// TextDecoder converts content to UTF-8, in one go or a window at a time.
+// Every character of the text is mapped back to its offset in the content.
+type TextDecoder struct {
+	// Name is the encoding of the content
+	Name string
+	enc  encoding.Encoding
+	dec  *encoding.Decoder
+	bom  int
+}
+
+// NewTextDecoder detects the encoding from the head of the content. It is
+// taken from the byte order mark, then from the declared charset, and is
+// sniffed otherwise: UTF-16 is told by its zero bytes, valid UTF-8 is kept
+// and anything else is read as windows-1252 like browsers do.
+func NewTextDecoder(head []byte, charset string) *TextDecoder {
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		return &TextDecoder{Name: "utf-8", bom: len(utf8BOM)}
+	case bytes.HasPrefix(head, utf16LEBOM):
+		return newTextDecoder(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "utf-16le", len(utf16LEBOM))
+	case bytes.HasPrefix(head, utf16BEBOM):
+		return newTextDecoder(unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), "utf-16be", len(utf16BEBOM))
+	}
+
+	if charset != "" {
+		enc, err := htmlindex.Get(charset)
+		if err == nil {
+			name, _ := htmlindex.Name(enc)
+			if name == "utf-8" {
+				return &TextDecoder{Name: name}
+			}
+			return newTextDecoder(enc, name, 0)
+		}
+		logger.Get().Debugf("unknown charset=%s, sniffing the encoding", charset)
+	}
+
+	if endianness, ok := sniffUTF16(head); ok {
+		name := "utf-16be"
+		if endianness == unicode.LittleEndian {
+			name = "utf-16le"
+		}
+		return newTextDecoder(unicode.UTF16(endianness, unicode.IgnoreBOM), name, 0)
+	}
+	if validUTF8(head) {
+		return &TextDecoder{Name: "utf-8"}
+	}
+	return newTextDecoder(charmap.Windows1252, "windows-1252", 0)
+}
+
+func newTextDecoder(enc encoding.Encoding, name string, bom int) *TextDecoder {
+	return &TextDecoder{Name: name, enc: enc, dec: enc.NewDecoder(), bom: bom}
+}
+
+// DecodeText converts content to UTF-8 with the encoding detected by
+// NewTextDecoder. The returned map leads offsets of the text back to content,
+// it is nil when content is UTF-8 already.
+func DecodeText(content []byte, charset string) ([]byte, *OffsetMap, string) {
+	d := NewTextDecoder(content, charset)
+	if d.enc == nil && d.bom == 0 {
+		return content, nil, d.Name
+	}
+
+	text, m, _ := d.Decode(content, 0, true)
+	return text, m, d.Name
+}
+
+// Decode converts a window of the content starting at offset base. Unless
+// atEOF, a character cut at the end of the window is left out; the number of
+// bytes converted is returned along with the text and its offset map.
+func (d *TextDecoder) Decode(content []byte, base int, atEOF bool) ([]byte, *OffsetMap, int) {
+	start := 0
+	if base == 0 {
+		start = d.bom
+	}
+
+	m := &OffsetMap{}
+	if d.enc == nil {
+		m.add(0, base+start, 1, 1)
+		return content[start:], m, len(content)
+	}
+
+	// ASCII bytes stand for themselves in all but the UTF-16 encodings and the
+	// stateful ISO-2022-JP
+	ascii := d.Name != "utf-16le" && d.Name != "utf-16be" && d.Name != "iso-2022-jp"
+
+	text := make([]byte, 0, len(content))
+	var buf [16]byte
+
+	i := start
+	for i < len(content) {
+		if ascii && content[i] < utf8.RuneSelf {
+			m.add(len(text), base+i, 1, 1)
+			text = append(text, content[i])
+			i++
+			continue
+		}
+
+		// the source grows until a whole character is decoded
+		nDst, nSrc := 0, 0
+		for n := 1; n <= utf8.UTFMax && nSrc == 0; n++ {
+			end := i + n
+			if end > len(content) {
+				break
+			}
+			nDst, nSrc, _ = d.dec.Transform(buf[:], content[i:end], atEOF && end == len(content))
+		}
+		if nSrc == 0 {
+			if !atEOF && len(content)-i < utf8.UTFMax {
+				// the rest of the character is in the next window
+				break
+			}
+			nDst, nSrc = utf8.EncodeRune(buf[:], utf8.RuneError), 1
+		}
+
+		m.add(len(text), base+i, nDst, nSrc)
+		text = append(text, buf[:nDst]...)
+		i += nSrc
+	}
+
+	return text, m, i
+}
+
+// validUTF8 reports whether content is UTF-8, allowing for a character cut
+// at its end.
+func validUTF8(content []byte) bool {
+	for i := len(content) - 1; i >= 0 && i >= len(content)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(content[i]) {
+			if !utf8.FullRune(content[i:]) {
+				content = content[:i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(content)
+}
+
+// sniffUTF16 tells UTF-16 without a byte order mark from the zero high bytes
+// of ASCII characters.
+func sniffUTF16(content []byte) (unicode.Endianness, bool) {
+	if len(content) > sniffLength {
+		content = content[:sniffLength]
+	}
+
+	pairs := len(content) / 2
+	if pairs < 2 {
+		return false, false
+	}
+
+	var even, odd int
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i] == 0 {
+			even++
+		}
+		if content[i+1] == 0 {
+			odd++
+		}
+	}
+
+	switch {
+	case odd*2 > pairs && even*10 < pairs:
+		return unicode.LittleEndian, true
+	case even*2 > pairs && odd*10 < pairs:
+		return unicode.BigEndian, true
+	}
+	return false, false
+}
+
+// OffsetMap leads offsets of text converted to UTF-8 back to the original
+// content. It holds runs of characters with the same lengths before and after
+// the conversion, which keeps it small for mostly ASCII scripts.
+type OffsetMap struct {
+	runs []offsetRun
+}
+
+// offsetRun starts at text and orig, each of its characters is textLen bytes
+// long in the text and origLen bytes long in the original content.
+type offsetRun struct {
+	text, orig       int
+	textLen, origLen int
+}
+
+func (m *OffsetMap) add(text, orig, textLen, origLen int) {
+	if textLen == 0 {
+		return
+	}
+
+	if n := len(m.runs); n > 0 {
+		r := m.runs[n-1]
+		if r.textLen == textLen && r.origLen == origLen &&
+			r.orig+(text-r.text)/textLen*origLen == orig {
+			return
+		}
+	}
+	m.runs = append(m.runs, offsetRun{text: text, orig: orig, textLen: textLen, origLen: origLen})
+}
+
+// Original returns the offset in the original content of the character at
+// offset in the text.
+func (m *OffsetMap) Original(offset int) int {
+	if m == nil {
+		return offset
+	}
+
+	i := sort.Search(len(m.runs), func(i int) bool {
+		return m.runs[i].text > offset
+	}) - 1
+	if i < 0 {
+		return offset
+	}
+
+	r := m.runs[i]
+	return r.orig + (offset-r.text)/r.textLen*r.origLen
+}
@@ -0,0 +1,22 @@
+package strategies
+
+// ContentStrategy scans content that is already in memory, like an inline
+// script of an HTML page. Charset is the declared charset of the content, if
+// it is known.
+type ContentStrategy struct {
+	Name    string
+	Content []byte
+	Charset string
+}
+
+func (cs ContentStrategy) GetContent() ([]byte, error) {
+	return cs.Content, nil
+}
+
+func (cs ContentStrategy) GetEncodedContent() ([]byte, string, error) {
+	return cs.Content, cs.Charset, nil
+}
+
+func (cs ContentStrategy) GetFileName() string {
+	return cs.Name
+}
@@ -0,0 +1,139 @@
+package strategies
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding is sent with every HTTP request, responses are decoded
+// according to their Content-Encoding.
+const acceptEncoding = "br, zstd, gzip"
+
+// compressedExtensions maps the extensions of pre-compressed files to their
+// encoding.
+var compressedExtensions = map[string]string{
+	".gz":  "gzip",
+	".br":  "br",
+	".zst": "zstd",
+}
+
+// CompressionOf returns the encoding of a pre-compressed file like app.js.gz,
+// or an empty string.
+func CompressionOf(name string) string {
+	return compressedExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// TrimCompression removes the compression extension of a file name.
+func TrimCompression(name string) string {
+	if CompressionOf(name) == "" {
+		return name
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// decompressor reads through a chain of decoders and closes all of them
+// along with the underlying reader.
+type decompressor struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decompressor) Close() error {
+	var err error
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if cerr := d.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// limitedReader fails once more than limit bytes are read, so decompression
+// bombs are cut short.
+type limitedReader struct {
+	io.ReadCloser
+	name  string
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, fmt.Errorf("decompressed content is larger than the size limit target=%s limit=%d", l.name, l.limit)
+	}
+	return n, err
+}
+
+// newDecompressor decodes rc by the encodings of a Content-Encoding header,
+// listed in the order they were applied: gzip, br, zstd or deflate.
+func newDecompressor(encodings string, rc io.ReadCloser) (io.ReadCloser, error) {
+	d := &decompressor{Reader: rc, closers: []io.Closer{rc}}
+
+	list := strings.Split(encodings, ",")
+	for i := len(list) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(list[i]))
+
+		switch encoding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(d.Reader)
+			if err != nil {
+				d.Close()
+				return nil, fmt.Errorf("decoding %s content failed err: %w", encoding, err)
+			}
+			d.Reader = gz
+			d.closers = append(d.closers, gz)
+		case "br":
+			d.Reader = brotli.NewReader(d.Reader)
+		case "zstd":
+			zr, err := zstd.NewReader(d.Reader)
+			if err != nil {
+				d.Close()
+				return nil, fmt.Errorf("decoding %s content failed err: %w", encoding, err)
+			}
+			d.Reader = zr
+			d.closers = append(d.closers, zr.IOReadCloser())
+		case "deflate":
+			zr, err := zlib.NewReader(d.Reader)
+			if err != nil {
+				d.Close()
+				return nil, fmt.Errorf("decoding %s content failed err: %w", encoding, err)
+			}
+			d.Reader = zr
+			d.closers = append(d.closers, zr)
+		default:
+			d.Close()
+			return nil, fmt.Errorf("unsupported content encoding=%s", encoding)
+		}
+	}
+
+	return d, nil
+}
+
+// decompress decodes data by the encodings of a Content-Encoding header and
+// fails once the decoded content grows past limit bytes.
+func decompress(name, encodings string, data []byte, limit int64) ([]byte, error) {
+	d, err := newDecompressor(encodings, ioutil.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	decoded, err := ioutil.ReadAll(&limitedReader{ReadCloser: d, name: name, limit: limit})
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s content failed err: %w", encodings, err)
+	}
+	return decoded, nil
+}
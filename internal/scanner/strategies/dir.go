@@ -0,0 +1,179 @@
+package strategies
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// ScriptExtensions are the file extensions scanned when walking a directory.
+var ScriptExtensions = []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx"}
+
+// DirStrategy walks a directory tree, or the files matching a glob, and scans
+// every script in it. A glob target is matched against the whole path
+// relative to its root. Include and Exclude globs are matched against that
+// path too, patterns without a slash match the base name; a file must match
+// the glob target and one of the Include globs.
+type DirStrategy struct {
+	Target          string
+	Include         []string
+	Exclude         []string
+	SkipNodeModules bool
+}
+
+func (ds DirStrategy) GetContent() ([]byte, error) {
+	return nil, fmt.Errorf("%s is a directory, its files must be walked", ds.Target)
+}
+
+func (ds DirStrategy) GetFileName() string {
+	return ds.Target
+}
+
+func (ds DirStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected directory strategy target=%s", ds.Target)
+
+	// a directory named like a glob, e.g. pages/[slug], is walked as is
+	root, glob := ds.Target, []string(nil)
+	if _, err := os.Stat(ds.Target); err != nil && IsGlob(ds.Target) {
+		var pattern string
+		root, pattern = splitGlob(ds.Target)
+		glob = strings.Split(pattern, "/")
+	}
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if ds.SkipNodeModules && d.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			if rel != "." && matchAny(ds.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			if rel != "." && glob != nil && !globMayDescend(glob, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !IsScript(p) || matchAny(ds.Exclude, rel) {
+			return nil
+		}
+		if glob != nil && !matchSegments(glob, strings.Split(rel, "/")) {
+			return nil
+		}
+		if len(ds.Include) > 0 && !matchAny(ds.Include, rel) {
+			return nil
+		}
+
+		return fn(p, FileStrategy{Target: p})
+	})
+}
+
+// IsScript reports whether the file name has one of the script extensions,
+// pre-compressed scripts like app.js.gz included.
+func IsScript(name string) bool {
+	ext := strings.ToLower(path.Ext(TrimCompression(name)))
+	for _, e := range ScriptExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGlob reports whether the target contains glob meta characters.
+func IsGlob(target string) bool {
+	return strings.ContainsAny(target, "*?[")
+}
+
+// splitGlob splits a glob into the directory to walk from and the pattern
+// relative to it, e.g. "dist/**/*.js" becomes "dist" and "**/*.js".
+func splitGlob(pattern string) (root, rel string) {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, part := range parts {
+		if IsGlob(part) {
+			root = strings.Join(parts[:i], "/")
+			if root == "" {
+				root = "."
+				if i > 0 {
+					root = "/"
+				}
+			}
+			return filepath.FromSlash(root), strings.Join(parts[i:], "/")
+		}
+	}
+	return filepath.Dir(pattern), filepath.Base(pattern)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash separated path against a glob where "**" stands
+// for any number of directories.
+func matchGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// globMayDescend reports whether files under the directory rel may match
+// the glob, so directories out of its reach are not walked.
+func globMayDescend(glob []string, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		if i >= len(glob)-1 {
+			return false
+		}
+		if glob[i] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(glob[i], part); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
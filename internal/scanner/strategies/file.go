@@ -2,12 +2,16 @@ package strategies
 
 import (
 	"github.com/riza/linx/pkg/logger"
+	"io"
 	"io/ioutil"
 	"os"
 )
 
+// FileStrategy reads a local file. Pre-compressed files like app.js.gz are
+// decompressed, up to MaxSize bytes or DefaultMaxEntrySize when it is zero.
 type FileStrategy struct {
-	Target string
+	Target  string
+	MaxSize int64
 }
 
 func (fs FileStrategy) GetContent() ([]byte, error) {
@@ -15,6 +19,28 @@ func (fs FileStrategy) GetContent() ([]byte, error) {
 	return fs.readFileContent()
 }
 
+// OpenContent returns the file as a stream, decompressed when it is a
+// pre-compressed script.
+func (fs FileStrategy) OpenContent() (io.ReadCloser, string, error) {
+	logger.Get().Debugf("selected file content strategy target=%s", fs.Target)
+
+	f, err := os.Open(fs.Target)
+	if err != nil {
+		return nil, "", err
+	}
+
+	encoding := CompressionOf(fs.Target)
+	if encoding == "" {
+		return f, "", nil
+	}
+
+	content, err := newDecompressor(encoding, f)
+	if err != nil {
+		return nil, "", err
+	}
+	return &limitedReader{ReadCloser: content, name: fs.Target, limit: fs.maxSize()}, "", nil
+}
+
 func (fs FileStrategy) GetFileName() string {
 	return fs.Target
 }
@@ -25,10 +51,26 @@ func (fs FileStrategy) readFileContent() ([]byte, error) {
 		return nil, err
 	}
 
-	content, err := ioutil.ReadFile(fs.Target)
+	encoding := CompressionOf(fs.Target)
+	if encoding == "" {
+		return ioutil.ReadFile(fs.Target)
+	}
+
+	// pre-compressed files like app.js.gz are decoded transparently, the
+	// decompressed size is bounded so bombs can not exhaust memory
+	logger.Get().Debugf("decompressing %s file target=%s", encoding, fs.Target)
+	content, _, err := fs.OpenContent()
 	if err != nil {
 		return nil, err
 	}
+	defer content.Close()
+
+	return ioutil.ReadAll(content)
+}
 
-	return content, nil
+func (fs FileStrategy) maxSize() int64 {
+	if fs.MaxSize <= 0 {
+		return DefaultMaxEntrySize
+	}
+	return fs.MaxSize
 }
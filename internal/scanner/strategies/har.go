@@ -0,0 +1,86 @@
+package strategies
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// HARStrategy scans the JavaScript responses recorded in a HAR file, and the
+// inline scripts of its HTML responses, with the entry URL as the source.
+type HARStrategy struct {
+	Target string
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				URL string `json:"url"`
+			} `json:"request"`
+			Response struct {
+				Content struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func (hs HARStrategy) GetContent() ([]byte, error) {
+	return FileStrategy{Target: hs.Target}.GetContent()
+}
+
+func (hs HARStrategy) GetFileName() string {
+	return hs.Target
+}
+
+func (hs HARStrategy) Offline() {}
+
+func (hs HARStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected har strategy target=%s", hs.Target)
+
+	f, err := os.Open(hs.Target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var har harFile
+	if err := json.NewDecoder(f).Decode(&har); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range har.Log.Entries {
+		target, content := entry.Request.URL, entry.Response.Content
+		if seen[target] || content.Text == "" {
+			continue
+		}
+
+		body := []byte(content.Text)
+		if content.Encoding == "base64" {
+			body, err = base64.StdEncoding.DecodeString(content.Text)
+			if err != nil {
+				logger.Get().Warnf("har entry body can not be decoded url=%s err: %v", target, err)
+				continue
+			}
+		}
+
+		strategy, ok := recordedStrategy(target, content.MimeType, body)
+		if !ok {
+			continue
+		}
+		seen[target] = true
+
+		if err := fn(target, strategy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
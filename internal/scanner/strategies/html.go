@@ -0,0 +1,239 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+	"golang.org/x/net/html"
+)
+
+// HTMLStrategy reads an HTML page from Page and scans the scripts it
+// references or embeds. Script references are resolved against Target, or
+// against the page's <base href> when it has one. With InlineOnly, only the
+// inline scripts are scanned, e.g. for recorded pages. URL is where the page
+// was fetched from after redirects, references are resolved against it when
+// it is set.
+type HTMLStrategy struct {
+	Target     string
+	URL        string
+	Page       ScanStrategy
+	InlineOnly bool
+}
+
+// HTMLScripts holds the scripts found in an HTML page.
+type HTMLScripts struct {
+	// Base is the value of <base href>, if any
+	Base string
+	// Sources are the src of <script> and the href of preloaded scripts
+	Sources []string
+	// Inline are the bodies of inline <script> blocks
+	Inline [][]byte
+}
+
+func (hs HTMLStrategy) GetContent() ([]byte, error) {
+	return hs.Page.GetContent()
+}
+
+func (hs HTMLStrategy) GetFileName() string {
+	return hs.Target
+}
+
+func (hs HTMLStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected html strategy target=%s", hs.Target)
+
+	content, charset, err := GetEncodedContent(hs.Page)
+	if err != nil {
+		return err
+	}
+
+	// inline scripts are taken from the page converted to UTF-8
+	content, _, _ = DecodeText(content, charset)
+	scripts := ParseHTMLScripts(content)
+	logger.Get().Debugf("html page has %d script references and %d inline scripts target=%s",
+		len(scripts.Sources), len(scripts.Inline), hs.Target)
+
+	for i, inline := range scripts.Inline {
+		name := fmt.Sprintf("%s#inline-%d", hs.Target, i+1)
+		if err := fn(name, ContentStrategy{Name: name, Content: inline}); err != nil {
+			return err
+		}
+	}
+
+	if hs.InlineOnly {
+		return nil
+	}
+
+	location := hs.Target
+	if hs.URL != "" {
+		location = hs.URL
+	}
+
+	seen := make(map[string]bool)
+	for _, src := range scripts.Sources {
+		target, ok := ResolveReference(location, scripts.Base, src)
+		if !ok || seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		if err := fn(target, StrategyForReference(target)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseHTMLScripts collects <script src>, inline <script> blocks, preloaded
+// scripts and the <base href> of an HTML page.
+func ParseHTMLScripts(content []byte) HTMLScripts {
+	var scripts HTMLScripts
+
+	z := html.NewTokenizer(bytes.NewReader(content))
+	inScript := false
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return scripts
+
+		case html.TextToken:
+			if inScript {
+				text := bytes.TrimSpace(z.Text())
+				if len(text) > 0 {
+					scripts.Inline = append(scripts.Inline, append([]byte(nil), text...))
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == "script" {
+				inScript = false
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = strings.TrimSpace(string(val))
+			}
+
+			switch string(name) {
+			case "base":
+				if scripts.Base == "" && attrs["href"] != "" {
+					scripts.Base = attrs["href"]
+				}
+			case "script":
+				if src := attrs["src"]; src != "" {
+					scripts.Sources = append(scripts.Sources, src)
+				} else if tt == html.StartTagToken {
+					inScript = true
+				}
+			case "link":
+				if isScriptPreload(attrs["rel"], attrs["as"]) && attrs["href"] != "" {
+					scripts.Sources = append(scripts.Sources, attrs["href"])
+				}
+			}
+		}
+	}
+}
+
+func isScriptPreload(rel, as string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == "modulepreload" || (r == "preload" && strings.EqualFold(as, "script")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveReference resolves ref against base, or against target when base is
+// empty. Targets may be URLs or local paths; references of local pages that
+// are not URLs are resolved against the page's directory.
+func ResolveReference(target, base, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "javascript:") {
+		return "", false
+	}
+
+	if base != "" {
+		resolved, ok := ResolveReference(target, "", base)
+		if ok {
+			target = resolved
+			// a base href always points to a directory
+			if !strings.HasSuffix(target, "/") && !IsURL(target) {
+				target += "/"
+			}
+		}
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+
+	if IsURL(target) {
+		t, err := url.Parse(target)
+		if err != nil {
+			return "", false
+		}
+		resolved := t.ResolveReference(r)
+		resolved.Fragment = ""
+		return resolved.String(), true
+	}
+
+	if r.IsAbs() || strings.HasPrefix(ref, "//") {
+		if r.Scheme != "" && r.Scheme != "http" && r.Scheme != "https" {
+			return "", false
+		}
+		if r.Scheme == "" {
+			r.Scheme = "https"
+		}
+		return r.String(), true
+	}
+
+	dir := filepath.Dir(target)
+	if strings.HasSuffix(target, "/") {
+		dir = target
+	}
+	// root relative references of a local page are taken from its directory
+	return filepath.Join(dir, filepath.FromSlash(r.Path)), true
+}
+
+// StrategyForReference returns the strategy to fetch a resolved reference.
+func StrategyForReference(target string) ScanStrategy {
+	if IsURL(target) {
+		return URLStrategy{Target: target}
+	}
+	return FileStrategy{Target: target}
+}
+
+// IsHTML reports whether the target is an HTML page rather than a script: a
+// file or URL ending in .html or .htm. URLs without extension are told by
+// their response.
+func IsHTML(target string) bool {
+	p := target
+	if IsURL(target) {
+		u, err := url.Parse(target)
+		if err != nil {
+			return false
+		}
+		p = u.Path
+	}
+
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".html" || ext == ".htm"
+}
+
+// IsURL reports whether the target is an http or https URL.
+func IsURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
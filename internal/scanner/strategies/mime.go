@@ -0,0 +1,52 @@
+package strategies
+
+import (
+	"bytes"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// IsJavaScriptType reports whether the media type is a JavaScript one.
+func IsJavaScriptType(contentType string) bool {
+	mt := mediaType(contentType)
+	return strings.Contains(mt, "javascript") || strings.Contains(mt, "ecmascript") ||
+		mt == "text/jsx" || mt == "application/x-typescript" || mt == "application/typescript"
+}
+
+// IsHTMLType reports whether the media type is an HTML one.
+func IsHTMLType(contentType string) bool {
+	mt := mediaType(contentType)
+	return mt == "text/html" || mt == "application/xhtml+xml"
+}
+
+// htmlPrefixes are how HTML pages start, lowercase.
+var htmlPrefixes = []string{"<!doctype html", "<html", "<head", "<body", "<meta", "<title", "<script", "<!--"}
+
+// SniffHTML reports whether content starts like an HTML page.
+func SniffHTML(content []byte) bool {
+	content = bytes.TrimLeft(bytes.TrimPrefix(content, utf8BOM), " \t\r\n\f")
+	for _, p := range htmlPrefixes {
+		if len(content) >= len(p) && bytes.EqualFold(content[:len(p)], []byte(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScriptURL reports whether the path of a URL has a script extension.
+func IsScriptURL(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	return IsScript(u.Path)
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+	return strings.ToLower(mt)
+}
@@ -0,0 +1,27 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path"
+	"strings"
+)
+
+// hermesMagic starts every Hermes bytecode bundle.
+var hermesMagic = []byte{0xc6, 0x1f, 0xbc, 0x03, 0xc1, 0x03, 0x19, 0x1f}
+
+// IsReactNativeBundle reports whether the file name is a React Native bundle,
+// like assets/index.android.bundle in an APK or main.jsbundle in an IPA.
+func IsReactNativeBundle(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".bundle" || ext == ".jsbundle"
+}
+
+// HermesVersion reports whether the content is Hermes bytecode rather than
+// JavaScript source, and its bytecode version.
+func HermesVersion(content []byte) (uint32, bool) {
+	if len(content) < 12 || !bytes.HasPrefix(content, hermesMagic) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(content[8:12]), true
+}
@@ -0,0 +1,16 @@
+package strategies
+
+// recordedStrategy returns the strategy scanning a recorded response body:
+// JavaScript is scanned as is and HTML pages for their inline scripts. It
+// returns false for any other content.
+func recordedStrategy(target, contentType string, body []byte) (ScanStrategy, bool) {
+	content := ContentStrategy{Name: target, Content: body, Charset: charsetOf(contentType)}
+
+	switch {
+	case IsHTMLType(contentType):
+		return HTMLStrategy{Target: target, Page: content, InlineOnly: true}, true
+	case IsJavaScriptType(contentType) || IsScriptURL(target):
+		return content, true
+	}
+	return nil, false
+}
@@ -0,0 +1,206 @@
+package strategies
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/riza/linx/internal/httpclient"
+	"github.com/riza/linx/pkg/logger"
+)
+
+const requestLineRule = `^[A-Z]+ \S+ HTTP/\d\.\d\r?$`
+
+var requestLinePattern = regexp.MustCompile(requestLineRule)
+
+// hopHeaders belong to the captured connection and are not replayed.
+var hopHeaders = []string{"Connection", "Content-Length", "Transfer-Encoding", "Keep-Alive", "Proxy-Connection"}
+
+// RequestStrategy replays a raw HTTP/1.1 request file, like one saved with
+// Burp's "Copy to file", and scans the response body. Its method, headers
+// and body are sent as captured. BaseURL gives the scheme and host the
+// request is sent to; by default the host comes from the Host header and
+// the scheme is https, or http on port 80.
+type RequestStrategy struct {
+	Target  string
+	BaseURL string
+}
+
+// replayedRequest is the request of a request file, ready to be sent.
+type replayedRequest struct {
+	url    string
+	method string
+	host   string
+	header http.Header
+	body   []byte
+}
+
+func (rs RequestStrategy) GetContent() ([]byte, error) {
+	r, err := rs.request()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetContent()
+}
+
+func (rs RequestStrategy) GetFileName() string {
+	return rs.Target
+}
+
+// Walk scans the response attributed to the URL of the request, so the
+// references found in it are resolved against that URL.
+func (rs RequestStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected request strategy target=%s", rs.Target)
+
+	r, err := rs.request()
+	if err != nil {
+		return err
+	}
+	return fn(r.url, r)
+}
+
+// request parses the request file. The body is taken as it is in the file,
+// whatever its Content-Length says, since captured requests are often
+// edited by hand.
+func (rs RequestStrategy) request() (replayedRequest, error) {
+	content, err := ioutil.ReadFile(rs.Target)
+	if err != nil {
+		return replayedRequest{}, err
+	}
+
+	head, body := content, []byte(nil)
+	for _, sep := range []string{"\r\n\r\n", "\n\n"} {
+		if i := bytes.Index(content, []byte(sep)); i >= 0 {
+			head, body = content[:i], content[i+len(sep):]
+			break
+		}
+	}
+
+	req, err := http.ReadRequest(bufio.NewReader(io.MultiReader(bytes.NewReader(head), strings.NewReader("\r\n\r\n"))))
+	if err != nil {
+		return replayedRequest{}, fmt.Errorf("request file can not be parsed target=%s err: %v", rs.Target, err)
+	}
+
+	target, err := rs.requestURL(req)
+	if err != nil {
+		return replayedRequest{}, err
+	}
+
+	header := req.Header.Clone()
+	for _, h := range hopHeaders {
+		header.Del(h)
+	}
+
+	return replayedRequest{
+		url:    target,
+		method: req.Method,
+		host:   req.Host,
+		header: header,
+		body:   body,
+	}, nil
+}
+
+// requestURL builds the URL the request is sent to.
+func (rs RequestStrategy) requestURL(req *http.Request) (string, error) {
+	u := *req.URL
+	if u.Scheme != "" && u.Host != "" && rs.BaseURL == "" {
+		// the request line already holds an absolute URL
+		return u.String(), nil
+	}
+
+	u.Scheme, u.Host = "https", req.Host
+	if _, port, err := net.SplitHostPort(req.Host); err == nil && port == "80" {
+		u.Scheme = "http"
+	}
+
+	if rs.BaseURL != "" {
+		base, err := url.Parse(rs.BaseURL)
+		if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+			return "", fmt.Errorf("request base url is invalid, it must be like https://example.com base=%s", rs.BaseURL)
+		}
+		u.Scheme = base.Scheme
+		if base.Host != "" {
+			u.Host = base.Host
+		}
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("request file has no host, set one with the request base url target=%s", rs.Target)
+	}
+	return u.String(), nil
+}
+
+func (r replayedRequest) GetContent() ([]byte, error) {
+	content, _, err := r.GetEncodedContent()
+	return content, err
+}
+
+func (r replayedRequest) GetEncodedContent() ([]byte, string, error) {
+	body, charset, err := r.OpenContent()
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, charset, nil
+}
+
+// OpenContent sends the request and returns the decoded response body.
+func (r replayedRequest) OpenContent() (io.ReadCloser, string, error) {
+	logger.Get().Debugf("replaying %s request url=%s", r.method, r.url)
+
+	var body io.Reader
+	if len(r.body) > 0 {
+		body = bytes.NewReader(r.body)
+	}
+
+	req, err := httpclient.Get().NewRequest(r.method, r.url, body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the captured headers are sent as they are, the Host header included
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	if r.host != "" {
+		req.Host = r.host
+	}
+
+	resp, contentType, err := openResponse(req)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, charsetOf(contentType), nil
+}
+
+func (r replayedRequest) GetFileName() string {
+	return r.url
+}
+
+// IsRawRequest reports whether the file starts with an HTTP request line.
+func IsRawRequest(name string) bool {
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(io.LimitReader(f, 8*1024)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+	return requestLinePattern.MatchString(strings.TrimRight(line, "\n"))
+}
@@ -0,0 +1,28 @@
+package strategies
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// StdinStrategy scans raw JavaScript piped on stdin.
+type StdinStrategy struct {
+}
+
+func (ss StdinStrategy) GetContent() ([]byte, error) {
+	logger.Get().Debugf("selected stdin strategy")
+	return ioutil.ReadAll(os.Stdin)
+}
+
+// OpenContent returns stdin as a stream.
+func (ss StdinStrategy) OpenContent() (io.ReadCloser, string, error) {
+	logger.Get().Debugf("selected stdin strategy")
+	return ioutil.NopCloser(os.Stdin), "", nil
+}
+
+func (ss StdinStrategy) GetFileName() string {
+	return "stdin"
+}
@@ -1,6 +1,31 @@
 package strategies
 
+import "io"
+
 type ScanStrategy interface {
 	GetContent() ([]byte, error)
 	GetFileName() string
 }
+
+// MultiStrategy is a strategy whose target holds more than one script, like a
+// directory. Walk calls fn for every script with the name results should be
+// attributed to.
+type MultiStrategy interface {
+	ScanStrategy
+	Walk(fn func(source string, strategy ScanStrategy) error) error
+}
+
+// OfflineStrategy is implemented by strategies that read recorded traffic,
+// like HAR files. Nothing their scripts reference is fetched while scanning.
+type OfflineStrategy interface {
+	MultiStrategy
+	Offline()
+}
+
+// StreamStrategy is implemented by strategies that can read their content as
+// a stream, along with its declared charset, so scripts of any size are
+// scanned without being held in memory whole.
+type StreamStrategy interface {
+	ScanStrategy
+	OpenContent() (content io.ReadCloser, charset string, err error)
+}
@@ -1,11 +1,14 @@
 package strategies
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"path"
 
+	"github.com/riza/linx/internal/httpclient"
 	"github.com/riza/linx/pkg/logger"
 )
 
@@ -14,6 +17,29 @@ type URLStrategy struct {
 }
 
 func (us URLStrategy) GetContent() ([]byte, error) {
+	content, _, err := us.GetEncodedContent()
+	return content, err
+}
+
+// GetEncodedContent returns the response body along with the charset of its
+// Content-Type.
+func (us URLStrategy) GetEncodedContent() ([]byte, string, error) {
+	body, charset, err := us.OpenContent()
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, charset, nil
+}
+
+// OpenContent returns the decoded response body as a stream along with the
+// charset of its Content-Type.
+func (us URLStrategy) OpenContent() (io.ReadCloser, string, error) {
 	logger.Get().Debugf("selected url strategy target=%s", us.Target)
 	return us.getFileContent()
 }
@@ -23,34 +49,141 @@ func (us URLStrategy) GetFileName() string {
 	return file
 }
 
-func (us URLStrategy) getFileContent() ([]byte, error) {
+func (us URLStrategy) getFileContent() (io.ReadCloser, string, error) {
 	logger.Get().Debugf("getting file from %s", us.Target)
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", us.Target, nil)
+	// the configured headers, cookies and credentials are sent along
+	req, err := httpclient.Get().NewRequest("GET", us.Target, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	// Set default headers
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	body, contentType, err := openResponse(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// a URL without extension may be a page or a script, and single page apps
+	// answer missing scripts with their index.html. HTML pages are handed back
+	// to the scanner instead of being matched as scripts.
+	if !IsHTML(us.Target) && !IsJavaScriptType(contentType) {
+		br := bufio.NewReader(body.ReadCloser)
+		head, _ := br.Peek(sniffLength)
+		if SniffHTML(head) || (!IsScriptURL(us.Target) && IsHTMLType(contentType)) {
+			defer body.Close()
+			content, err := ioutil.ReadAll(br)
+			if err != nil {
+				return nil, "", err
+			}
+			return nil, "", &HTMLResponseError{
+				Target:      us.Target,
+				Redirects:   body.Redirects,
+				ContentType: contentType,
+				Content:     content,
+				Charset:     charsetOf(contentType),
+			}
+		}
+		body.ReadCloser = &decompressor{Reader: br, closers: []io.Closer{body.ReadCloser}}
+	}
+
+	return body, charsetOf(contentType), nil
+}
+
+// ResponseBody is the decoded body of a response along with the redirects
+// that led to it.
+type ResponseBody struct {
+	io.ReadCloser
+	// Redirects lists the URLs the request was redirected to, in order, the
+	// last one is where the body comes from
+	Redirects []string
+}
+
+// openResponse sends the request and returns the decoded body of a
+// successful response along with its Content-Type.
+func openResponse(req *http.Request) (*ResponseBody, string, error) {
+	client := httpclient.Get()
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	defer resp.Body.Close()
 
 	logger.Get().Debugf("response: status code=%d", resp.StatusCode)
+	if location := resp.Header.Get("Location"); resp.StatusCode >= 300 && resp.StatusCode <= 399 && location != "" {
+		resp.Body.Close()
+		// the redirect policy stopped here, the blocked hop ends the chain
+		blocked := location
+		if u, err := resp.Request.URL.Parse(location); err == nil {
+			blocked = u.String()
+		}
+		return nil, "", &RedirectError{
+			URL:       resp.Request.URL.String(),
+			Redirects: append(redirectsOf(resp), blocked),
+			Code:      resp.StatusCode,
+		}
+	}
 	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
-		return nil, fmt.Errorf("getting url content fail. status code is not success code=%d", resp.StatusCode)
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("getting url content fail. status code is not success code=%d", resp.StatusCode)
 	}
 
 	logger.Get().Debugf("response: content length=%d", resp.ContentLength)
-	body, err := ioutil.ReadAll(resp.Body)
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding != "" {
+		logger.Get().Debugf("response: content encoding=%s", encoding)
+	}
+
+	body, err := newDecompressor(encoding, resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	return &ResponseBody{
+		ReadCloser: client.LimitBody(body, req.URL.String()),
+		Redirects:  redirectsOf(resp),
+	}, resp.Header.Get("Content-Type"), nil
+}
+
+// redirectsOf lists the URLs the request of a response was redirected to.
+func redirectsOf(resp *http.Response) []string {
+	var redirects []string
+	for r := resp.Request; r != nil && r.Response != nil; r = r.Response.Request {
+		redirects = append([]string{r.URL.String()}, redirects...)
+	}
+	if len(redirects) > 0 {
+		logger.Get().Debugf("response: redirected url=%s", redirects[len(redirects)-1])
 	}
+	return redirects
+}
+
+// RedirectError is returned when the redirect policy stops a redirect.
+type RedirectError struct {
+	// URL is the URL that answered with the redirect
+	URL string
+	// Redirects lists the URLs the request was redirected to, the last one
+	// is the redirect that was not followed
+	Redirects []string
+	Code      int
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirect is not followed by the redirect policy url=%s location=%s code=%d", e.URL, e.Redirects[len(e.Redirects)-1], e.Code)
+}
+
+// HTMLResponseError is returned for a URL answered with an HTML page. For a
+// script URL it is most likely the index.html a single page app serves for
+// any missing path. It holds the page so it can still be scanned for scripts.
+type HTMLResponseError struct {
+	Target string
+	// Redirects lists the URLs the request was redirected to
+	Redirects   []string
+	ContentType string
+	Content     []byte
+	Charset     string
+}
 
-	return body, nil
+func (e *HTMLResponseError) Error() string {
+	return fmt.Sprintf("response is an html page url=%s content type=%s", e.Target, e.ContentType)
 }
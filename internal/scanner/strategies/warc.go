@@ -0,0 +1,155 @@
+package strategies
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/riza/linx/pkg/logger"
+)
+
+// maxRecordBodySize is the largest payload read from a WARC record, larger
+// ones are skipped so memory stays bounded.
+const maxRecordBodySize = 64 << 20
+
+// WARCStrategy streams the records of a WARC or WARC.gz archive and scans the
+// JavaScript and HTML payloads of its response and resource records, with
+// the record's target URI as the source.
+type WARCStrategy struct {
+	Target string
+}
+
+func (ws WARCStrategy) GetContent() ([]byte, error) {
+	return nil, fmt.Errorf("%s is a warc archive, its records must be walked", ws.Target)
+}
+
+func (ws WARCStrategy) GetFileName() string {
+	return ws.Target
+}
+
+func (ws WARCStrategy) Offline() {}
+
+func (ws WARCStrategy) Walk(fn func(source string, strategy ScanStrategy) error) error {
+	logger.Get().Debugf("selected warc strategy target=%s", ws.Target)
+
+	f, err := os.Open(ws.Target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(ws.Target), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+	tp := textproto.NewReader(br)
+
+	for {
+		version, err := tp.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// records are separated by blank lines
+		if version == "" {
+			continue
+		}
+		if !strings.HasPrefix(version, "WARC/") {
+			return fmt.Errorf("invalid warc record version line=%q", version)
+		}
+
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return err
+		}
+
+		length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid warc record content length err: %w", err)
+		}
+
+		block := io.LimitReader(br, length)
+		strategy, ok := ws.recordStrategy(header, block, length)
+		// whatever is left of the block is skipped
+		if _, err := io.Copy(ioutil.Discard, block); err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+		if err := fn(strings.Trim(header.Get("WARC-Target-URI"), "<>"), strategy); err != nil {
+			return err
+		}
+	}
+}
+
+// recordStrategy reads the payload of a response or resource record and
+// returns the strategy to scan it.
+func (ws WARCStrategy) recordStrategy(header textproto.MIMEHeader, block io.Reader, length int64) (ScanStrategy, bool) {
+	target := strings.Trim(header.Get("WARC-Target-URI"), "<>")
+	recordType := header.Get("WARC-Type")
+
+	var contentType, encoding string
+	var payload io.Reader
+
+	switch {
+	case recordType == "response" && strings.HasPrefix(header.Get("Content-Type"), "application/http"):
+		resp, err := http.ReadResponse(bufio.NewReader(block), nil)
+		if err != nil {
+			logger.Get().Warnf("warc response can not be parsed url=%s err: %v", target, err)
+			return nil, false
+		}
+		defer resp.Body.Close()
+		contentType, payload = resp.Header.Get("Content-Type"), resp.Body
+		encoding = resp.Header.Get("Content-Encoding")
+	case recordType == "resource":
+		contentType, payload = header.Get("Content-Type"), block
+	default:
+		return nil, false
+	}
+
+	if !IsHTMLType(contentType) && !IsJavaScriptType(contentType) && !IsScriptURL(target) {
+		return nil, false
+	}
+	if length > maxRecordBodySize {
+		logger.Get().Warnf("warc record is too large, skipped url=%s size=%d", target, length)
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(payload, maxRecordBodySize))
+	if err != nil && len(body) == 0 {
+		logger.Get().Warnf("warc payload can not be read url=%s err: %v", target, err)
+		return nil, false
+	}
+
+	body, err = decompress(target, encoding, body, maxRecordBodySize)
+	if err != nil {
+		logger.Get().Warnf("warc payload can not be decoded url=%s err: %v", target, err)
+		return nil, false
+	}
+
+	return recordedStrategy(target, contentType, body)
+}
+
+// IsWARC reports whether the file name is a WARC or WARC.gz archive.
+func IsWARC(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".warc") || strings.HasSuffix(name, ".warc.gz")
+}
@@ -0,0 +1,306 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/riza/linx/internal/scanner/strategies"
+	"github.com/riza/linx/pkg/logger"
+)
+
+const (
+	// chunk url builders of the webpack 5 runtime (__webpack_require__.u), of
+	// webpack 4 (jsonpScriptSrc) and of minified webpack 4, where the builder
+	// is a function returning the public path joined with the chunk name
+	webpackChunkURLRule = `([\w$]+)\.u\s*=\s*(?:function\s*\(\s*([\w$]+)\s*\)\s*\{|\(?\s*([\w$]+)\s*\)?\s*=>)|function\s+jsonpScriptSrc\s*\(\s*([\w$]+)\s*\)\s*\{|function\s*[\w$]*\s*\(\s*([\w$]+)\s*\)\s*\{\s*return\s+([\w$]+\.p)\s*\+`
+
+	// banners prefixed to every line of non-minified webpack 4 runtimes
+	webpackBanner = "/******/"
+
+	// the url builder and its id maps are read from this many bytes at most
+	maxChunkBuilderLength = 256 * 1024
+
+	webpackObjectEntryRule = `(?:"([^"]*)"|'([^']*)'|([\w$]+))\s*:\s*(?:"([^"]*)"|'([^']*)')`
+)
+
+var (
+	webpackChunkURLPattern    = regexp.MustCompile(webpackChunkURLRule)
+	webpackObjectEntryPattern = regexp.MustCompile(webpackObjectEntryRule)
+)
+
+// chunks queues the lazily loaded webpack chunks of a runtime. The chunk url
+// builder is evaluated for every chunk id found in its id maps and each url
+// is resolved against the public path.
+func (s scanner) chunks(u unit, content []byte, j *job) {
+	location := s.location(u)
+
+	for _, chunk := range webpackChunks(string(content)) {
+		target, ok := resolveChunk(location, chunk)
+		if ok && j.discovered(u, location, target, u.depth) {
+			logger.Get().Debugf("webpack: queued chunk=%s", target)
+		}
+	}
+}
+
+// webpackChunks returns the chunk urls, prefixed by the public path, built by
+// the webpack runtime in the content.
+func webpackChunks(content string) []string {
+	var chunks []string
+
+	for _, m := range webpackChunkURLPattern.FindAllStringSubmatchIndex(content, -1) {
+		param := ""
+		for g := 2; g <= 5; g++ {
+			if m[2*g] >= 0 {
+				param = content[m[2*g]:m[2*g+1]]
+			}
+		}
+
+		// the minified webpack 4 builder is matched up to its public path,
+		// which starts the returned expression
+		start, returned := m[1], m[12] >= 0
+		if returned {
+			start = m[12]
+		}
+
+		body := content[start:]
+		if len(body) > maxChunkBuilderLength {
+			body = body[:maxChunkBuilderLength]
+		}
+		body = strings.ReplaceAll(body, webpackBanner, "")
+		if !returned && (strings.HasPrefix(strings.TrimSpace(body), "{") || content[m[1]-1] == '{') {
+			i := returnIndex(body)
+			if i < 0 {
+				continue
+			}
+			body = body[i+len("return"):]
+		}
+
+		expr := chunkExpression(body)
+		publicPath := webpackPublicPath(content, webpackRuntime(content, m, expr))
+
+		for _, id := range chunkIDs(expr) {
+			chunk, ok := evalChunkExpression(expr, param, id)
+			if ok && strategies.IsScript(chunk) {
+				chunks = append(chunks, publicPath+chunk)
+			}
+		}
+	}
+
+	return chunks
+}
+
+// returnIndex finds the first return statement of a function body, skipping
+// line comments like the ones of development builds.
+func returnIndex(body string) int {
+	offset := 0
+	for _, line := range strings.SplitAfter(body, "\n") {
+		code := line
+		if c := strings.Index(code, "//"); c >= 0 {
+			code = code[:c]
+		}
+		if i := strings.Index(code, "return"); i >= 0 {
+			return offset + i
+		}
+		offset += len(line)
+	}
+	return -1
+}
+
+// webpackRuntime returns the name of the runtime object, taken from the .u
+// assignment or from the public path used in the url builder.
+func webpackRuntime(content string, m []int, expr string) string {
+	if m[2] >= 0 {
+		return content[m[2]:m[3]]
+	}
+
+	for _, term := range splitTopLevel(expr, "+") {
+		if term = strings.TrimSpace(term); strings.HasSuffix(term, ".p") {
+			return strings.TrimSuffix(term, ".p")
+		}
+	}
+	return "__webpack_require__"
+}
+
+// webpackPublicPath finds the string assigned to the runtime's public path.
+// Automatic public paths are left empty, relative to the runtime script.
+func webpackPublicPath(content, runtime string) string {
+	p := regexp.MustCompile(regexp.QuoteMeta(runtime) + `\.p\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	m := p.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	return m[1] + m[2]
+}
+
+// chunkExpression cuts the returned expression from the start of body, up to
+// the first top level statement or argument separator, or the end of the line
+// when the expression is not continued on the next one.
+func chunkExpression(body string) string {
+	depth := 0
+	var quote byte
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '{', '[':
+			depth++
+		case ')', '}', ']':
+			if depth == 0 {
+				return body[:i]
+			}
+			depth--
+		case ';', ',':
+			if depth == 0 {
+				return body[:i]
+			}
+		case '\n':
+			if depth == 0 && !continuesLine(body[:i], body[i+1:]) {
+				return body[:i]
+			}
+		}
+	}
+	return body
+}
+
+// continuesLine reports whether an expression broken at a line end goes on
+// in the next line, joined by a + at the end of one or the start of the other.
+func continuesLine(before, after string) bool {
+	return strings.TrimSpace(before) == "" ||
+		strings.HasSuffix(strings.TrimSpace(before), "+") ||
+		strings.HasPrefix(strings.TrimSpace(after), "+")
+}
+
+// splitTopLevel splits expr on sep where it is not nested or quoted.
+func splitTopLevel(expr, sep string) []string {
+	var parts []string
+	depth, last := 0, 0
+	var quote byte
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '{', '[':
+			depth++
+		case ')', '}', ']':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(expr[i:], sep) {
+				parts = append(parts, expr[last:i])
+				i += len(sep) - 1
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, expr[last:])
+}
+
+// chunkIDs collects the keys of every id map in the expression.
+func chunkIDs(expr string) []string {
+	seen := map[string]bool{}
+	var ids []string
+
+	for _, m := range webpackObjectEntryPattern.FindAllStringSubmatch(expr, -1) {
+		id := m[1] + m[2] + m[3]
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// evalChunkExpression evaluates the url builder for a chunk id. It supports
+// string literals, the id parameter, the public path, id maps like
+// {1:"a"}[e] and fallbacks like ({1:"a"}[e]||e).
+func evalChunkExpression(expr, param, id string) (string, bool) {
+	var b strings.Builder
+
+	for _, term := range splitTopLevel(expr, "+") {
+		v, ok := evalChunkTerm(strings.TrimSpace(term), param, id)
+		if !ok {
+			return "", false
+		}
+		b.WriteString(v)
+	}
+	return b.String(), true
+}
+
+func evalChunkTerm(term, param, id string) (string, bool) {
+	switch {
+	case term == "":
+		return "", true
+	case term == param:
+		return id, true
+	case strings.HasSuffix(term, ".p"):
+		// the public path is prepended by the caller
+		return "", true
+	case len(term) >= 2 && (term[0] == '"' || term[0] == '\'') && term[len(term)-1] == term[0]:
+		return term[1 : len(term)-1], true
+	case strings.HasPrefix(term, "(") && strings.HasSuffix(term, ")"):
+		for _, alt := range splitTopLevel(term[1:len(term)-1], "||") {
+			if v, ok := evalChunkExpression(alt, param, id); ok {
+				return v, true
+			}
+		}
+		return "", false
+	case strings.HasPrefix(term, "{") && strings.HasSuffix(term, "["+param+"]"):
+		for _, m := range webpackObjectEntryPattern.FindAllStringSubmatch(term, -1) {
+			if m[1]+m[2]+m[3] == id {
+				return m[4] + m[5], true
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// resolveChunk resolves a chunk url against the runtime script. For local
+// files the chunk is looked up from the script's directory and its parents,
+// since the site root is not known.
+func resolveChunk(location, chunk string) (string, bool) {
+	if strategies.IsURL(location) {
+		return strategies.ResolveReference(location, "", chunk)
+	}
+
+	if strategies.IsURL(chunk) || strings.HasPrefix(chunk, "//") {
+		return strategies.ResolveReference(location, "", chunk)
+	}
+
+	rel := filepath.FromSlash(strings.TrimPrefix(chunk, "/"))
+	for dir := filepath.Dir(location); ; dir = filepath.Dir(dir) {
+		candidate := filepath.Join(dir, rel)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", false
+		}
+	}
+}